@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AuthKeyEnv - переменная окружения с ключом авторизации для NewClientFromEnv
+const AuthKeyEnv = "GIGACHAT_AUTH_KEY"
+
+// AuthStrategy формирует запрос на получение токена доступа, позволяя заменить
+// способ авторизации, например на обмен токенов
+type AuthStrategy interface {
+	// TokenRequest возвращает тело и заголовки запроса токена с областью доступа scope.
+	// authorization - значение заголовка Authorization, построенное из ключа авторизации клиента.
+	TokenRequest(ctx context.Context, scope Scope, authorization string) ([]byte, http.Header, error)
+}
+
+// ClientCredentialsStrategy - способ авторизации по умолчанию: форма scope=... и ключ
+// авторизации в заголовке Authorization
+type ClientCredentialsStrategy struct{}
+
+func (ClientCredentialsStrategy) TokenRequest(
+	ctx context.Context, scope Scope, authorization string,
+) ([]byte, http.Header, error) {
+	header := make(http.Header)
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	header.Set("Authorization", authorization)
+
+	return []byte(fmt.Sprintf("scope=%s", scope)), header, nil
+}
+
+// ValidateAuthKey проверяет, что ключ авторизации - это base64 строки вида
+// "client_id:client_secret". Позволяет обнаружить поврежденный ключ при запуске,
+// а не по ответу 401 на первый запрос.
+func ValidateAuthKey(key string) error {
+	if key == "" {
+		return errors.New("invalid auth key: empty")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("invalid auth key: not valid base64: %w", err)
+	}
+
+	clientID, secret, ok := strings.Cut(string(decoded), ":")
+	if !ok || clientID == "" || secret == "" {
+		return errors.New("invalid auth key: expected base64 of client_id:client_secret")
+	}
+
+	return nil
+}
+
+// NewClientFromEnv создает клиент с ключом авторизации из переменной окружения
+// GIGACHAT_AUTH_KEY, предварительно проверив его через ValidateAuthKey
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	key := strings.TrimSpace(os.Getenv(AuthKeyEnv))
+	if err := ValidateAuthKey(key); err != nil {
+		return nil, fmt.Errorf("%s: %w", AuthKeyEnv, err)
+	}
+
+	return NewClient(key, opts...), nil
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestValidateAuthKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "valid", key: base64.StdEncoding.EncodeToString([]byte("client-id:secret"))},
+		{name: "empty", key: "", wantErr: true},
+		{name: "not base64", key: "not base64!", wantErr: true},
+		{name: "no separator", key: base64.StdEncoding.EncodeToString([]byte("client-id")), wantErr: true},
+		{name: "empty secret", key: base64.StdEncoding.EncodeToString([]byte("client-id:")), wantErr: true},
+		{name: "empty client id", key: base64.StdEncoding.EncodeToString([]byte(":secret")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAuthKey(tt.key)
+			if tt.wantErr && err == nil {
+				t.Error("Expected error for malformed auth key")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected valid auth key, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("client-id:secret"))
+	t.Setenv(AuthKeyEnv, key)
+
+	client, err := NewClientFromEnv(WithBaseURL("https://example.com/api/v1"))
+	if err != nil {
+		t.Fatalf("NewClientFromEnv failed: %v", err)
+	}
+
+	if client.authorization != "Basic "+key {
+		t.Errorf("Expected authorization to be 'Basic %s', got '%s'", key, client.authorization)
+	}
+
+	if client.baseURL != "https://example.com/api/v1" {
+		t.Errorf("Expected options to be applied, got baseURL '%s'", client.baseURL)
+	}
+
+	t.Setenv(AuthKeyEnv, "garbled")
+	if _, err := NewClientFromEnv(); err == nil {
+		t.Error("Expected error for malformed auth key in environment")
+	}
+}
+
+// tokenExchangeStrategy обменивает внешний токен на токен доступа GigaChat
+type tokenExchangeStrategy struct {
+	subjectToken string
+}
+
+func (s tokenExchangeStrategy) TokenRequest(
+	ctx context.Context, scope Scope, authorization string,
+) ([]byte, http.Header, error) {
+	form := url.Values{
+		"grant_type":    {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token": {s.subjectToken},
+		"scope":         {string(scope)},
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	header.Set("X-Exchange", "true")
+
+	return []byte(form.Encode()), header, nil
+}
+
+func TestAuthStrategy(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	var (
+		body   url.Values
+		header http.Header
+	)
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(data))
+		header = r.Header.Clone()
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "exchanged_token",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	client := srv.client(WithAuthStrategy(tokenExchangeStrategy{subjectToken: "external"}))
+
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if body.Get("grant_type") != "urn:ietf:params:oauth:grant-type:token-exchange" {
+		t.Errorf("Expected token-exchange grant type, got '%s'", body.Get("grant_type"))
+	}
+
+	if body.Get("subject_token") != "external" || body.Get("scope") != string(GIGACHAT_API_PERS) {
+		t.Errorf("Expected subject token and scope in body, got %v", body)
+	}
+
+	if header.Get("X-Exchange") != "true" {
+		t.Errorf("Expected strategy header to be sent, got '%s'", header.Get("X-Exchange"))
+	}
+
+	if header.Get("Authorization") != "" {
+		t.Errorf("Expected no Authorization header, got '%s'", header.Get("Authorization"))
+	}
+
+	if header.Get("RqUID") == "" || header.Get("Accept") != "application/json" {
+		t.Errorf("Expected client to keep RqUID and Accept headers, got %v", header)
+	}
+}
+
+func TestClientCredentialsStrategy(t *testing.T) {
+	body, header, err := ClientCredentialsStrategy{}.TokenRequest(
+		context.Background(), GIGACHAT_API_CORP, "Basic key",
+	)
+	if err != nil {
+		t.Fatalf("TokenRequest failed: %v", err)
+	}
+
+	if string(body) != "scope=GIGACHAT_API_CORP" {
+		t.Errorf("Expected 'scope=GIGACHAT_API_CORP', got '%s'", body)
+	}
+
+	if header.Get("Authorization") != "Basic key" {
+		t.Errorf("Expected Authorization 'Basic key', got '%s'", header.Get("Authorization"))
+	}
+
+	if header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("Expected form content type, got '%s'", header.Get("Content-Type"))
+	}
+}
+
+func TestAuthStrategyNil(t *testing.T) {
+	client := NewClient("key", WithAuthStrategy(nil))
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err == nil {
+		t.Error("Expected error for nil auth strategy")
+	}
+}
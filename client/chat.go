@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChatDefaults содержит параметры генерации, подставляемые в запросы на чат,
+// в которых соответствующие поля не заданы
+type ChatDefaults struct {
+	Temperature *float64
+	TopP        *float64
+	N           *int
+	MaxTokens   *int
+}
+
+// applyTo заполняет незаданные поля запроса значениями по умолчанию
+func (d ChatDefaults) applyTo(req *ChatRequest) {
+	if req.Temperature == nil {
+		req.Temperature = d.Temperature
+	}
+	if req.TopP == nil {
+		req.TopP = d.TopP
+	}
+	if req.N == nil {
+		req.N = d.N
+	}
+	if req.MaxTokens == nil {
+		req.MaxTokens = d.MaxTokens
+	}
+}
+
+// GenerationConfig содержит параметры генерации, которые можно один раз задать
+// и применять ко многим запросам. Поля те же, что у ChatDefaults, но ApplyTo
+// заменяет значения, уже указанные в запросе. Nil поля не применяются, поэтому
+// нулевое значение, например Temperature 0, задается явно.
+type GenerationConfig ChatDefaults
+
+// ApplyTo задает в запросе заданные (не nil) параметры генерации, заменяя уже указанные.
+// Каждый запрос получает собственные копии значений.
+func (g GenerationConfig) ApplyTo(req *ChatRequest) {
+	if g.Temperature != nil {
+		req.Temperature = ptr(*g.Temperature)
+	}
+	if g.TopP != nil {
+		req.TopP = ptr(*g.TopP)
+	}
+	if g.N != nil {
+		req.N = ptr(*g.N)
+	}
+	if g.MaxTokens != nil {
+		req.MaxTokens = ptr(*g.MaxTokens)
+	}
+}
+
+// ptr возвращает указатель на копию v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// ChatResult представляет ответ чата в упрощенном виде
+type ChatResult struct {
+	ID      string
+	Model   string
+	Choices []ChatResultChoice
+	Usage   Usage
+}
+
+// ChatResultChoice представляет один вариант ответа модели
+type ChatResultChoice struct {
+	Index        int
+	Content      string
+	FinishReason FinishReason
+	// FunctionCall не nil, если модель запросила вызов функции
+	FunctionCall *FunctionCall
+}
+
+// Content возвращает текст первого варианта ответа
+func (r *ChatResult) Content() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].Content
+}
+
+// ChatDetailed выполняет запрос к чату как Chat и возвращает ответ в виде ChatResult
+func (c *Client) ChatDetailed(ctx context.Context, req *ChatRequest) (*ChatResult, error) {
+	resp, err := c.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChatResult{
+		ID:      resp.ID,
+		Model:   resp.Model,
+		Choices: make([]ChatResultChoice, len(resp.Choices)),
+		Usage:   resp.Usage,
+	}
+	for i, choice := range resp.Choices {
+		result.Choices[i] = ChatResultChoice{
+			Index:        choice.Index,
+			Content:      choice.Message.Content,
+			FinishReason: choice.FinishReason,
+			FunctionCall: choice.Message.FunctionCall,
+		}
+	}
+
+	return result, nil
+}
+
+// prepareChatRequest возвращает проверенную копию запроса с примененными настройками клиента.
+// Запрос вызывающего кода не изменяется.
+func (c *Client) prepareChatRequest(ctx context.Context, req *ChatRequest) (*ChatRequest, error) {
+	prepared := *req
+	for _, hook := range c.chatRequestHooks {
+		hook(&prepared)
+	}
+	c.chatDefaults.applyTo(&prepared)
+	capMaxTokens(ctx, &prepared)
+
+	if err := prepared.validate(c.maxMessages); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkModelAllowed(prepared.Model); err != nil {
+		return nil, err
+	}
+
+	if c.pinnedModels != nil {
+		// Если сервер не перечисляет версии (только "GigaChat"), запрос отправляется с исходным именем
+		model, err := c.ResolveModel(ctx, prepared.Model)
+		if err != nil && !errors.Is(err, errNoModelVersions) {
+			return nil, err
+		}
+		if err == nil {
+			prepared.Model = model
+		}
+	}
+
+	if c.validateFunctionSupport && len(prepared.Functions) > 0 && !SupportsFunctions(prepared.Model) {
+		return nil, fmt.Errorf("%w: model %q does not support functions", ErrNotSupported, prepared.Model)
+	}
+
+	return &prepared, nil
+}
+
+// checkResponseModel проверяет, что модель ответа относится к тому же семейству, что и запрошенная.
+// Семейство определяется именем модели без версии: "GigaChat:latest" и "GigaChat:1.0.26.20" совместимы.
+func checkResponseModel(requested, got string) error {
+	if got == "" {
+		return nil
+	}
+
+	if !strings.EqualFold(modelFamily(requested), modelFamily(got)) {
+		return fmt.Errorf("%w: requested %q, got %q", ErrModelMismatch, requested, got)
+	}
+
+	return nil
+}
+
+// modelFamily возвращает имя модели без версии
+func modelFamily(model string) string {
+	family, _, _ := strings.Cut(model, ":")
+	return family
+}
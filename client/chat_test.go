@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestChatDefaults(t *testing.T) {
+	var requests []ChatRequest
+	client := newTestClient(t, echoChatHandler(t, &requests), WithChatDefaults(ChatDefaults{
+		Temperature: ptr(0.3),
+		TopP:        ptr(0.9),
+		N:           ptr(1),
+		MaxTokens:   ptr(100),
+	}))
+
+	req := &ChatRequest{
+		Model:     "GigaChat:latest",
+		Messages:  []ChatMessage{{Role: RoleUser, Content: "Hello"}},
+		MaxTokens: ptr(500),
+	}
+	if _, err := client.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	sent := requests[0]
+	if sent.Temperature == nil || *sent.Temperature != 0.3 {
+		t.Errorf("Expected default temperature 0.3, got %v", sent.Temperature)
+	}
+
+	if sent.TopP == nil || *sent.TopP != 0.9 {
+		t.Errorf("Expected default top_p 0.9, got %v", sent.TopP)
+	}
+
+	if sent.N == nil || *sent.N != 1 {
+		t.Errorf("Expected default n 1, got %v", sent.N)
+	}
+
+	if sent.MaxTokens == nil || *sent.MaxTokens != 500 {
+		t.Errorf("Expected explicit max_tokens 500 to win, got %v", sent.MaxTokens)
+	}
+
+	if req.Temperature != nil {
+		t.Error("Expected caller's request not to be modified")
+	}
+}
+
+func TestValidateResponseModel(t *testing.T) {
+	tests := []struct {
+		requested string
+		got       string
+		mismatch  bool
+	}{
+		{requested: "GigaChat:latest", got: "GigaChat:1.0.26.20"},
+		{requested: "GigaChat", got: "GigaChat:1.0.26.20"},
+		{requested: "GigaChat-Pro", got: "GigaChat-Pro"},
+		{requested: "GigaChat-Pro", got: "GigaChat:1.0.26.20", mismatch: true},
+	}
+
+	for _, tt := range tests {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(ChatResponse{Model: tt.got})
+		}, WithValidateResponseModel())
+
+		_, err := client.Chat(context.Background(), &ChatRequest{Model: tt.requested})
+		if tt.mismatch && !errors.Is(err, ErrModelMismatch) {
+			t.Errorf("Expected ErrModelMismatch for %s -> %s, got %v", tt.requested, tt.got, err)
+		}
+		if !tt.mismatch && err != nil {
+			t.Errorf("Expected no error for %s -> %s, got %v", tt.requested, tt.got, err)
+		}
+	}
+}
+
+func TestResponseModelNotValidatedByDefault(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{Model: "GigaChat:1.0.26.20"})
+	})
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat-Max"}); err != nil {
+		t.Errorf("Expected no error without validation, got %v", err)
+	}
+}
+
+func TestChatRequestHook(t *testing.T) {
+	var requests []ChatRequest
+	client := newTestClient(t, echoChatHandler(t, &requests),
+		WithChatRequestHook(func(req *ChatRequest) {
+			if req.MaxTokens == nil {
+				req.MaxTokens = ptr(256)
+			}
+		}),
+		WithChatRequestHook(func(req *ChatRequest) {
+			req.Messages = append([]ChatMessage{{Role: RoleSystem, Content: "Be brief"}}, req.Messages...)
+		}),
+	)
+
+	req := &ChatRequest{
+		Model:    "GigaChat:latest",
+		Messages: []ChatMessage{{Role: RoleUser, Content: "Hello"}},
+	}
+	if _, err := client.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	sent := requests[0]
+	if sent.MaxTokens == nil || *sent.MaxTokens != 256 {
+		t.Errorf("Expected hook max_tokens 256, got %v", sent.MaxTokens)
+	}
+
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != RoleSystem {
+		t.Errorf("Expected hooks to run in order and prepend system prompt, got %+v", sent.Messages)
+	}
+
+	if req.MaxTokens != nil || len(req.Messages) != 1 {
+		t.Error("Expected caller's request not to be modified")
+	}
+}
+
+func TestChatDetailed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"id": "chat-1",
+			"model": "GigaChat:1.0.26.20",
+			"choices": [
+				{"index": 0, "message": {"role": "assistant", "content": "Sunny"}, "finish_reason": "stop"},
+				{"index": 1, "message": {"role": "assistant", "function_call": {"name": "get_weather", "arguments": {"city": "Moscow"}}}, "finish_reason": "function_call"}
+			],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+		}`))
+	})
+
+	result, err := client.ChatDetailed(context.Background(), &ChatRequest{Model: "GigaChat:latest", N: ptr(2)})
+	if err != nil {
+		t.Fatalf("ChatDetailed failed: %v", err)
+	}
+
+	if result.ID != "chat-1" || result.Model != "GigaChat:1.0.26.20" || result.Usage.TotalTokens != 15 {
+		t.Errorf("Expected response metadata and usage, got %+v", result)
+	}
+
+	if len(result.Choices) != 2 {
+		t.Fatalf("Expected 2 choices, got %d", len(result.Choices))
+	}
+
+	if first := result.Choices[0]; first.Content != "Sunny" || first.FinishReason != FinishStop || first.FunctionCall != nil {
+		t.Errorf("Unexpected first choice %+v", first)
+	}
+
+	second := result.Choices[1]
+	if second.Index != 1 || second.FinishReason != FinishFunctionCall || second.FunctionCall == nil || second.FunctionCall.Name != "get_weather" {
+		t.Errorf("Unexpected second choice %+v", second)
+	}
+
+	if result.Content() != "Sunny" {
+		t.Errorf("Expected Content to be 'Sunny', got '%s'", result.Content())
+	}
+}
+
+func TestGenerationConfig(t *testing.T) {
+	config := GenerationConfig{Temperature: ptr(0.0), MaxTokens: ptr(256)}
+
+	first := &ChatRequest{Model: "GigaChat:latest", TopP: ptr(0.9), MaxTokens: ptr(10)}
+	second := &ChatRequest{Model: "GigaChat:latest"}
+	config.ApplyTo(first)
+	config.ApplyTo(second)
+
+	if first.Temperature == nil || *first.Temperature != 0 {
+		t.Errorf("Expected explicit zero Temperature to be applied, got %v", first.Temperature)
+	}
+
+	if first.MaxTokens == nil || *first.MaxTokens != 256 {
+		t.Errorf("Expected MaxTokens to be overridden to 256, got %v", first.MaxTokens)
+	}
+
+	if first.TopP == nil || *first.TopP != 0.9 {
+		t.Errorf("Expected TopP to be kept at 0.9, got %v", first.TopP)
+	}
+
+	if first.N != nil || second.TopP != nil {
+		t.Errorf("Expected nil config fields to be skipped, got N %v and TopP %v", first.N, second.TopP)
+	}
+
+	if second.Temperature == first.Temperature || first.Temperature == config.Temperature {
+		t.Error("Expected requests not to share config pointers")
+	}
+}
+
+func TestErrorOnEmptyChoices(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"chat-1","choices":[]}`))
+	}
+
+	client := newTestClient(t, handler, WithErrorOnEmptyChoices())
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); !errors.Is(err, ErrNoChoices) {
+		t.Errorf("Expected ErrNoChoices, got %v", err)
+	}
+
+	client = newTestClient(t, handler)
+	resp, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("Expected empty response without the option, got %v", err)
+	}
+	if len(resp.Choices) != 0 {
+		t.Errorf("Expected no choices, got %d", len(resp.Choices))
+	}
+}
@@ -2,18 +2,29 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/time/rate"
 )
 
 type Scope string
@@ -39,14 +50,91 @@ const (
 	RoleFunction  Role = "function"
 )
 
+// IsValid сообщает, принимает ли GigaChat эту роль сообщения
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleSystem, RoleUser, RoleAssistant, RoleFunction:
+		return true
+	}
+	return false
+}
+
 // Client представляет клиент для работы с GigaChat API
 type Client struct {
 	httpClient    *http.Client
 	baseURL       string
 	authURL       string
 	authorization string
-	accessToken   string
-	tokenExpiry   time.Time
+	authStrategy  AuthStrategy
+	// tokenMu защищает authorization, authGeneration, accessToken, tokenExpiry и tokenScope
+	tokenMu sync.RWMutex
+	// authGeneration увеличивается при каждой смене ключа авторизации
+	authGeneration uint64
+	accessToken    string
+	tokenExpiry    time.Time
+	tokenScope     string
+
+	// пути методов API относительно baseURL
+	chatPath       string
+	embeddingsPath string
+	modelsPath     string
+	filesPath      string
+
+	authScheme       string
+	streamBufferSize int
+	// streamHeartbeatTimeout - максимальная пауза между данными потока
+	streamHeartbeatTimeout time.Duration
+	logger                 *slog.Logger
+	forceTokenRefresh      bool
+	compression            bool
+	onTokenRefresh         func(token string, expiry time.Time)
+	onResponseHeader       func(path string, h http.Header)
+	requestTimeout         time.Duration
+	requestEditors         []func(*http.Request) error
+	transportEditors       []func(*http.Transport)
+	requestIDGenerator     func() string
+	embeddingCache         *embeddingCache
+	// batchConcurrency - число пакетов CreateEmbeddingsBatched, отправляемых одновременно
+	batchConcurrency      int
+	modelsCache           *modelsCache
+	chatDefaults          ChatDefaults
+	defaultUploadPurpose  Purpose
+	chatRequestHooks      []func(*ChatRequest)
+	maxMessages           int
+	allowedModels         []string
+	validateResponseModel bool
+	errorOnEmptyChoices   bool
+	// validateFunctionSupport включает проверку поддержки функций моделью перед запросом
+	validateFunctionSupport bool
+	rateLimiter             *rate.Limiter
+	maxRetries              int
+	retryNonIdempotent      bool
+	retryErrorCodes         []int
+	onRetry                 func(attempt int, err error, delay time.Duration)
+	// methodOverride включает отправку GET и DELETE как POST с X-HTTP-Method-Override
+	methodOverride  bool
+	retryBackoff    time.Duration
+	maxRetryElapsed time.Duration
+
+	// backgroundRefresh - интервал фонового обновления токена, 0 - обновление выключено
+	backgroundRefresh time.Duration
+	newTicker         func(time.Duration) (<-chan time.Time, func())
+	stopRefresh       context.CancelFunc
+	refreshDone       chan struct{}
+
+	// errorBodyLimit ограничивает размер тела ответа с ошибкой, читаемого в APIError
+	errorBodyLimit int64
+	// debugBodies включает сохранение тела запроса в APIError
+	debugBodies bool
+
+	// configErr содержит первую ошибку, обнаруженную при применении опций
+	configErr error
+
+	mu      sync.Mutex
+	closed  bool
+	streams map[*ChatStreamReader]struct{}
+	// pinnedModels хранит версии моделей, закрепленные WithModelPinning
+	pinnedModels map[string]string
 }
 
 // NewClient создает новый клиент GigaChat
@@ -56,15 +144,58 @@ func NewClient(authKey string, opts ...Option) *Client {
 		baseURL:       "https://gigachat.devices.sberbank.ru/api/v1",
 		authURL:       "https://ngw.devices.sberbank.ru:9443/api/v2/oauth",
 		authorization: "Basic " + authKey,
+		authStrategy:  ClientCredentialsStrategy{},
+
+		chatPath:           "/chat/completions",
+		embeddingsPath:     "/embeddings",
+		modelsPath:         "/models",
+		filesPath:          "/files",
+		authScheme:         "Bearer",
+		streamBufferSize:   defaultStreamBufferSize,
+		requestIDGenerator: uuid.NewString,
+		retryBackoff:       defaultRetryBackoff,
+		errorBodyLimit:     defaultErrorBodyLimit,
+		newTicker:          newTicker,
 	}
 
 	for _, opt := range opts {
 		opt(cl)
 	}
+	cl.applyTransportEditors()
+
+	if cl.backgroundRefresh > 0 && cl.configErr == nil {
+		cl.startBackgroundRefresh()
+	}
 
 	return cl
 }
 
+// Close закрывает клиент и прерывает все открытые потоки.
+// После закрытия запросы клиента возвращают ErrClientClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	streams := c.streams
+	c.streams = nil
+	c.mu.Unlock()
+
+	for stream := range streams {
+		stream.abort()
+	}
+
+	c.stopBackgroundRefresh()
+
+	return nil
+}
+
+// isClosed сообщает, был ли клиент закрыт
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
 // TokenResponse представляет ответ на запрос токена
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
@@ -82,6 +213,8 @@ type Model struct {
 // ModelsResponse представляет ответ со списком моделей
 type ModelsResponse struct {
 	Data []Model `json:"data"`
+	// Stale сообщает, что список взят из кэша, потому что обновить его не удалось
+	Stale bool `json:"-"`
 }
 
 // Message представляет сообщение в чате
@@ -92,9 +225,16 @@ type Message struct {
 
 // Function представляет функцию для вызова
 type Function struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description,omitempty"`
-	Parameters  map[string]any `json:"parameters,omitempty"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description,omitempty"`
+	Parameters      map[string]any   `json:"parameters,omitempty"`
+	FewShotExamples []FewShotExample `json:"few_shot_examples,omitempty"`
+}
+
+// FewShotExample представляет пример запроса пользователя и аргументов функции для него
+type FewShotExample struct {
+	Request string         `json:"request"`
+	Params  map[string]any `json:"params"`
 }
 
 // FunctionCall представляет вызов функции
@@ -103,11 +243,72 @@ type FunctionCall struct {
 	Arguments map[string]any `json:"arguments"`
 }
 
+// Unmarshal декодирует аргументы вызова функции в v, например в структуру с параметрами функции
+func (f *FunctionCall) Unmarshal(v any) error {
+	data, err := json.Marshal(f.Arguments)
+	if err != nil {
+		return fmt.Errorf("failed to encode function arguments: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode function arguments: %w", err)
+	}
+
+	return nil
+}
+
 // ChatMessage представляет сообщение в чате с возможными функциями
 type ChatMessage struct {
 	Role         Role          `json:"role"`
 	Content      string        `json:"content,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+	Attachments  []string      `json:"attachments,omitempty"`
+}
+
+// NewMultimodalMessage создает сообщение с текстом и вложенными файлами,
+// загруженными заранее через UploadFile
+func NewMultimodalMessage(role Role, text string, fileIDs ...string) ChatMessage {
+	return ChatMessage{
+		Role:        role,
+		Content:     text,
+		Attachments: slices.Clone(fileIDs),
+	}
+}
+
+// clone возвращает глубокую копию сообщения
+func (m ChatMessage) clone() ChatMessage {
+	m.Attachments = slices.Clone(m.Attachments)
+	if m.FunctionCall != nil {
+		call := *m.FunctionCall
+		call.Arguments = maps.Clone(call.Arguments)
+		m.FunctionCall = &call
+	}
+	return m
+}
+
+// HasContent сообщает, содержит ли сообщение текст.
+// Сообщение ассистента может одновременно содержать текст и вызов функции.
+func (m ChatMessage) HasContent() bool {
+	return m.Content != ""
+}
+
+// imageTagPattern находит теги изображений, которыми GigaChat ссылается на сгенерированные файлы
+var imageTagPattern = regexp.MustCompile(`<img\s+[^>]*src="([^"]+)"`)
+
+// ImageFileIDs возвращает идентификаторы файлов изображений, сгенерированных моделью.
+// GigaChat возвращает их в тексте ответа в виде тегов <img src="ID" fuse="true"/>;
+// содержимое изображения можно получить через DownloadFile.
+func (m ChatMessage) ImageFileIDs() []string {
+	var ids []string
+	for _, match := range imageTagPattern.FindAllStringSubmatch(m.Content, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+// HasFunctionCall сообщает, содержит ли сообщение вызов функции
+func (m ChatMessage) HasFunctionCall() bool {
+	return m.FunctionCall != nil
 }
 
 // ChatRequest представляет запрос на чат
@@ -123,6 +324,14 @@ type ChatRequest struct {
 	FunctionCall any           `json:"function_call,omitempty"`
 }
 
+// EnableImageGeneration разрешает модели генерировать изображения.
+// Отдельного флага для этого в GigaChat API нет: изображения создает встроенная функция text2image,
+// которая вызывается только при function_call "auto". Режим function_call, заданный ранее, заменяется.
+// Размер изображения в API не настраивается; его можно лишь попросить в тексте запроса.
+func (r *ChatRequest) EnableImageGeneration() {
+	r.FunctionCall = "auto"
+}
+
 // ChatResponse представляет ответ от чата
 type ChatResponse struct {
 	ID      string       `json:"id"`
@@ -135,16 +344,59 @@ type ChatResponse struct {
 
 // ChatChoice представляет выбор модели
 type ChatChoice struct {
-	Index   int         `json:"index"`
-	Message ChatMessage `json:"message"`
-	Delta   ChatMessage `json:"delta,omitempty"`
+	Index        int          `json:"index"`
+	Message      ChatMessage  `json:"message"`
+	Delta        ChatMessage  `json:"delta,omitempty"`
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+}
+
+// FinishReason описывает причину завершения генерации
+type FinishReason string
+
+const (
+	// FinishStop - модель закончила ответ или встретила стоп-последовательность
+	FinishStop FinishReason = "stop"
+	// FinishLength - ответ обрезан по лимиту max_tokens
+	FinishLength FinishReason = "length"
+	// FinishFunctionCall - модель запросила вызов функции
+	FinishFunctionCall FinishReason = "function_call"
+	// FinishBlacklist - запрос попал под тематические ограничения
+	FinishBlacklist FinishReason = "blacklist"
+	// FinishError - генерация завершилась ошибкой
+	FinishError FinishReason = "error"
+)
+
+// IsStop сообщает, завершила ли модель ответ штатно
+func (r FinishReason) IsStop() bool {
+	return r == FinishStop
+}
+
+// IsLength сообщает, обрезан ли ответ по лимиту токенов
+func (r FinishReason) IsLength() bool {
+	return r == FinishLength
+}
+
+// IsFunctionCall сообщает, запросила ли модель вызов функции
+func (r FinishReason) IsFunctionCall() bool {
+	return r == FinishFunctionCall
+}
+
+// IsBlacklist сообщает, попал ли запрос под тематические ограничения
+func (r FinishReason) IsBlacklist() bool {
+	return r == FinishBlacklist
+}
+
+// IsError сообщает, завершилась ли генерация ошибкой
+func (r FinishReason) IsError() bool {
+	return r == FinishError
 }
 
 // Usage представляет использование токенов
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens          int `json:"prompt_tokens"`
+	CompletionTokens      int `json:"completion_tokens"`
+	TotalTokens           int `json:"total_tokens"`
+	PrecachedPromptTokens int `json:"precached_prompt_tokens,omitempty"`
 }
 
 // File представляет файл в хранилище
@@ -160,6 +412,8 @@ type File struct {
 // FilesResponse представляет ответ со списком файлов
 type FilesResponse struct {
 	Data []File `json:"data"`
+	// HasMore сообщает, что после этой страницы есть еще файлы
+	HasMore bool `json:"has_more,omitempty"`
 }
 
 // EmbeddingRequest представляет запрос на создание эмбеддингов
@@ -173,27 +427,53 @@ type EmbeddingResponse struct {
 	Object string      `json:"object"`
 	Data   []Embedding `json:"data"`
 	Usage  Usage       `json:"usage"`
+	// PromptTokensPerInput содержит число токенов каждого входного текста в порядке Input.
+	// Заполняется, только если сервер сообщил его для всех текстов.
+	PromptTokensPerInput []int `json:"-"`
 }
 
 // Embedding представляет эмбеддинг
 type Embedding struct {
-	Object    string    `json:"object"`
-	Embedding []float64 `json:"embedding"`
-	Index     int       `json:"index"`
+	Object    string          `json:"object"`
+	Embedding []float64       `json:"embedding"`
+	Index     int             `json:"index"`
+	Usage     *EmbeddingUsage `json:"usage,omitempty"`
+}
+
+// EmbeddingUsage представляет использование токенов для одного входного текста
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
 }
 
 // GetAccessToken получает токен доступа
 func (c *Client) GetAccessToken(ctx context.Context, scope Scope) error {
-	data := fmt.Sprintf("scope=%s", scope)
-	req, err := http.NewRequestWithContext(ctx, "POST", c.authURL, bytes.NewBufferString(data))
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.tokenMu.RLock()
+	authorization := c.authorization
+	generation := c.authGeneration
+	c.tokenMu.RUnlock()
+
+	data, header, err := c.authStrategy.TokenRequest(ctx, scope, authorization)
+	if err != nil {
+		return fmt.Errorf("failed to build token request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.authURL, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("RqUID", uuid.New().String())
-	req.Header.Set("Authorization", c.authorization)
+	req.Header.Set("RqUID", c.requestIDGenerator())
+	for key, values := range header {
+		req.Header[key] = values
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -202,8 +482,7 @@ func (c *Client) GetAccessToken(ctx context.Context, scope Scope) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("auth failed with status %d: %s", resp.StatusCode, string(body))
+		return c.newAPIError(authOp, resp)
 	}
 
 	var tokenResp TokenResponse
@@ -211,33 +490,139 @@ func (c *Client) GetAccessToken(ctx context.Context, scope Scope) error {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	expiry := time.Unix(tokenResp.ExpiresAt, 0)
+
+	claims, _ := parseTokenClaims(tokenResp.AccessToken)
+	expiry, err = checkTokenClaims(claims, scope, expiry)
+	if err != nil {
+		return err
+	}
+
+	c.tokenMu.Lock()
+	if c.authGeneration != generation {
+		// Ключ сменили, пока запрос выполнялся: токен старого ключа отбрасывается
+		c.tokenMu.Unlock()
+		return c.GetAccessToken(ctx, scope)
+	}
 	c.accessToken = tokenResp.AccessToken
-	c.tokenExpiry = time.Unix(tokenResp.ExpiresAt, 0)
+	c.tokenExpiry = expiry
+	c.tokenScope = claims.Scope
+	c.tokenMu.Unlock()
+
+	if c.onTokenRefresh != nil {
+		c.onTokenRefresh(tokenResp.AccessToken, expiry)
+	}
 
 	return nil
 }
 
+// withTimeout ограничивает время выполнения запроса значением из WithRequestTimeout,
+// если у ctx еще нет дедлайна
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
 // ensureToken проверяет и обновляет токен при необходимости
 func (c *Client) ensureToken(ctx context.Context) error {
-	if c.accessToken == "" || time.Now().After(c.tokenExpiry.Add(-5*time.Minute)) {
+	if c.configErr != nil {
+		return c.configErr
+	}
+	token, expiry := c.token()
+	if c.forceTokenRefresh || forceRefreshFromContext(ctx) || token == "" || time.Now().After(expiry.Add(-5*time.Minute)) {
 		return c.GetAccessToken(ctx, GIGACHAT_API_PERS)
 	}
 	return nil
 }
 
+// SetAuthorization заменяет ключ авторизации и сбрасывает полученный токен,
+// так что следующий запрос получит новый токен с новым ключом.
+// Позволяет сменить учетные данные, не создавая клиент заново.
+func (c *Client) SetAuthorization(authKey string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.authorization = "Basic " + authKey
+	c.authGeneration++
+	c.accessToken = ""
+	c.tokenExpiry = time.Time{}
+	c.tokenScope = ""
+}
+
+// token возвращает текущий токен доступа и время его истечения
+func (c *Client) token() (string, time.Time) {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
+	return c.accessToken, c.tokenExpiry
+}
+
 // makeRequest выполняет HTTP запрос с автоматическим обновлением токена
 func (c *Client) makeRequest(ctx context.Context, method, path string, body any) (*http.Response, error) {
-	if err := c.ensureToken(ctx); err != nil {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
 		return nil, err
 	}
 
+	if c.methodOverride && (method == http.MethodGet || method == http.MethodDelete) {
+		req.Header.Set(methodOverrideHeader, method)
+		req.Method = http.MethodPost
+	}
+
+	return c.do(ctx, req)
+}
+
+// methodOverrideHeader передает исходный метод запроса, отправленного как POST
+const methodOverrideHeader = "X-HTTP-Method-Override"
+
+// requestMethod возвращает исходный метод запроса с учетом X-HTTP-Method-Override
+func requestMethod(req *http.Request) string {
+	if method := req.Header.Get(methodOverrideHeader); method != "" {
+		return method
+	}
+	return req.Method
+}
+
+// doJSON выполняет запрос к API с телом body в формате JSON и декодирует ответ в T.
+// Ответ со статусом, отличным от 200, возвращается как APIError с операцией op;
+// noun называет ответ в ошибке декодирования, например "models".
+func doJSON[T any](ctx context.Context, c *Client, op, noun, method, path string, body any) (*T, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.makeRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.newAPIError(op, resp)
+	}
+
+	var result T
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", noun, err)
+	}
+
+	return &result, nil
+}
+
+// newRequest создает запрос к API с телом в формате JSON
+func (c *Client) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+		reqBody = bytes.NewReader(jsonBody)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
@@ -246,23 +631,116 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body any)
 	}
 
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := c.httpClient.Do(req)
+	return req, nil
+}
+
+// do отправляет запрос, повторяя его при временных ошибках, и логирует результат
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	maxRetries := c.maxRetries
+	if n, ok := retryCountFromContext(ctx); ok {
+		maxRetries = n
+	}
+	idempotent := c.retryNonIdempotent || isIdempotent(requestMethod(req))
+
+	var (
+		resp  *http.Response
+		err   error
+		begin = time.Now()
+	)
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err = c.send(ctx, req)
+		c.logRequest(ctx, req, resp, err, time.Since(start))
+
+		if attempt >= maxRetries || !c.canRetry(ctx, req, resp, err, idempotent) {
+			break
+		}
+
+		delay := c.retryDelay(attempt, resp)
+		if c.maxRetryElapsed > 0 && time.Since(begin)+delay > c.maxRetryElapsed {
+			break
+		}
+		if c.onRetry != nil {
+			c.onRetry(attempt+1, retryReason(resp, err), delay)
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	captureResponse(ctx, resp)
+	if c.onResponseHeader != nil {
+		c.onResponseHeader(req.URL.Path, resp.Header)
+	}
+
+	if c.compression {
+		if err := decompressResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// send отправляет запрос с токеном доступа и повторяет его при истекшем токене
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiter: %w", err)
+		}
+	}
+
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Header.Get("X-Request-ID") == "" {
+		req.Header.Set("X-Request-ID", c.requestIDGenerator())
+	}
+	if c.compression {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if sessionID := sessionIDFromContext(ctx); sessionID != "" {
+		req.Header.Set("X-Session-ID", sessionID)
+	}
+
+	resp, err := c.sendAuthorized(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+
 		// Попробуем обновить токен и повторить запрос
 		if err := c.GetAccessToken(ctx, GIGACHAT_API_PERS); err != nil {
 			return nil, fmt.Errorf("failed to refresh token: %w", err)
 		}
-		req.Header.Set("Authorization", "Bearer "+c.accessToken)
-		resp, err = c.httpClient.Do(req)
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+		resp, err = c.sendAuthorized(req)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retry request: %w", err)
 		}
@@ -271,67 +749,182 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body any)
 	return resp, nil
 }
 
-// GetModels получает список доступных моделей
-func (c *Client) GetModels(ctx context.Context) (*ModelsResponse, error) {
-	resp, err := c.makeRequest(ctx, "GET", "/models", nil)
+// sendAuthorized подставляет текущий токен, применяет редакторы запроса и отправляет его
+func (c *Client) sendAuthorized(req *http.Request) (*http.Response, error) {
+	token, _ := c.token()
+	req.Header.Set("Authorization", c.authScheme+" "+token)
+
+	for _, edit := range c.requestEditors {
+		if err := edit(req); err != nil {
+			return nil, fmt.Errorf("request editor failed: %w", err)
+		}
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// gzipReadCloser читает распакованное тело ответа и закрывает исходное
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
+// decompressResponse распаковывает тело ответа, сжатое gzip
+func decompressResponse(resp *http.Response) error {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return nil
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to decompress response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get models with status %d: %s", resp.StatusCode, string(body))
+	resp.Body = &gzipReadCloser{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return nil
+}
+
+// logRequest пишет в лог информацию о выполненном запросе
+func (c *Client) logRequest(
+	ctx context.Context, req *http.Request, resp *http.Response, err error, elapsed time.Duration,
+) {
+	if c.logger == nil {
+		return
 	}
 
-	var models ModelsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
-		return nil, fmt.Errorf("failed to decode models response: %w", err)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", elapsed),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if tags, ok := tagsAttr(ctx); ok {
+		attrs = append(attrs, tags)
 	}
 
-	return &models, nil
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "gigachat request", attrs...)
+}
+
+// GetModels получает список доступных моделей
+func (c *Client) GetModels(ctx context.Context) (*ModelsResponse, error) {
+	if c.modelsCache != nil {
+		return c.getModelsCached(ctx)
+	}
+
+	return c.getModels(ctx)
+}
+
+// getModels запрашивает список моделей у API
+func (c *Client) getModels(ctx context.Context) (*ModelsResponse, error) {
+	return doJSON[ModelsResponse](ctx, c, "get models", "models", "GET", c.modelsPath, nil)
 }
 
 // Chat выполняет запрос к чату
 func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
-	resp, err := c.makeRequest(ctx, "POST", "/chat/completions", req)
+	req, err := c.prepareChatRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to chat with status %d: %s", resp.StatusCode, string(body))
+	chatResp, err := doJSON[ChatResponse](ctx, c, "chat", "chat", "POST", c.chatPath, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.validateResponseModel {
+		if err := checkResponseModel(req.Model, chatResp.Model); err != nil {
+			return nil, err
+		}
 	}
 
-	var chatResp ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	if c.errorOnEmptyChoices && len(chatResp.Choices) == 0 {
+		return nil, ErrNoChoices
 	}
 
-	return &chatResp, nil
+	return chatResp, nil
 }
 
 // CreateEmbeddings создает эмбеддинги для текста
 func (c *Client) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
-	resp, err := c.makeRequest(ctx, "POST", "/embeddings", req)
+	if err := c.checkModelAllowed(req.Model); err != nil {
+		return nil, err
+	}
+
+	if c.embeddingCache != nil {
+		return c.createEmbeddingsCached(ctx, req)
+	}
+
+	return c.createEmbeddings(ctx, req)
+}
+
+// CreateEmbedding создает эмбеддинг для одного текста
+func (c *Client) CreateEmbedding(ctx context.Context, model, text string) (*Embedding, error) {
+	resp, err := c.CreateEmbeddings(ctx, &EmbeddingRequest{
+		Model: model,
+		Input: []string{text},
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to create embeddings with status %d: %s", resp.StatusCode, string(body))
+	return &resp.Data[0], nil
+}
+
+// createEmbeddings запрашивает эмбеддинги у API
+func (c *Client) createEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	embeddingResp, err := doJSON[EmbeddingResponse](ctx, c, "create embeddings", "embeddings", "POST", c.embeddingsPath, req)
+	if err != nil {
+		return nil, err
 	}
 
-	var embeddingResp EmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	if err := sortEmbeddings(embeddingResp.Data, len(req.Input)); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
 	}
+	embeddingResp.fillPromptTokensPerInput()
 
-	return &embeddingResp, nil
+	return embeddingResp, nil
+}
+
+// quoteEscaper экранирует имя файла в заголовке Content-Disposition
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartOverhead - примерный размер заголовков и полей multipart тела загрузки
+const multipartOverhead = 1024
+
+// readerSize возвращает число байт, оставшихся в r, если его можно определить без чтения
+func readerSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		size := info.Size()
+		if seeker, ok := r.(io.Seeker); ok {
+			if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+				size -= pos
+			}
+		}
+		return size, true
+	}
+	return 0, false
 }
 
 // UploadFile загружает файл в хранилище
@@ -359,23 +952,60 @@ func (c *Client) UploadFile(
 	)
 }
 
+// UploadFileOptions содержит дополнительные параметры загрузки файла
+type UploadFileOptions struct {
+	// ExtraFields добавляются в multipart-тело запроса наряду с purpose
+	ExtraFields map[string]string
+	// MaxBytesPerSecond ограничивает скорость отправки файла; 0 - без ограничения
+	MaxBytesPerSecond int
+}
+
+// UploadFileDefault загружает файл с назначением, заданным WithDefaultUploadPurpose
+func (c *Client) UploadFileDefault(ctx context.Context, filePath string) (*File, error) {
+	if c.defaultUploadPurpose == "" {
+		return nil, fmt.Errorf("default upload purpose is not set, use WithDefaultUploadPurpose")
+	}
+
+	return c.UploadFile(ctx, filePath, c.defaultUploadPurpose)
+}
+
+// UploadFileReader загружает в хранилище содержимое r под именем fileName
 func (c *Client) UploadFileReader(
 	ctx context.Context,
 	r io.Reader, fileName string, contentType string,
 	purpose Purpose,
 ) (*File, error) {
+	return c.UploadFileReaderWithOptions(ctx, r, fileName, contentType, purpose, UploadFileOptions{})
+}
+
+// UploadFileReaderWithOptions загружает файл как UploadFileReader с дополнительными параметрами
+func (c *Client) UploadFileReaderWithOptions(
+	ctx context.Context,
+	r io.Reader, fileName string, contentType string,
+	purpose Purpose, opts UploadFileOptions,
+) (*File, error) {
+	if strings.TrimSpace(fileName) == "" {
+		return nil, fmt.Errorf("invalid file name: must not be empty")
+	}
 	if contentType == "" || contentType == "application/octet-stream" {
 		return nil, fmt.Errorf("invalid content type: %s", contentType)
 	}
 
-	if err := c.ensureToken(ctx); err != nil {
-		return nil, err
-	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
 	var buf bytes.Buffer
+	if size, ok := readerSize(r); ok {
+		buf.Grow(int(size) + multipartOverhead)
+	}
 	writer := multipart.NewWriter(&buf)
 
-	part, err := writer.CreateFormFile("file", fileName)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(fileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
@@ -388,26 +1018,38 @@ func (c *Client) UploadFileReader(
 		return nil, fmt.Errorf("failed to write purpose field: %w", err)
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(opts.ExtraFields)) {
+		if err := writer.WriteField(name, opts.ExtraFields[name]); err != nil {
+			return nil, fmt.Errorf("failed to write %s field: %w", name, err)
+		}
+	}
+
 	writer.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/files", &buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+c.filesPath, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := c.httpClient.Do(req)
+	if opts.MaxBytesPerSecond > 0 {
+		body := buf.Bytes()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(newThrottledReader(ctx, bytes.NewReader(body), opts.MaxBytesPerSecond)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	resp, err := c.do(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to upload file with status %d: %s", resp.StatusCode, string(body))
+		return nil, c.newAPIError("upload file", resp)
 	}
 
 	var uploadedFile File
@@ -420,57 +1062,45 @@ func (c *Client) UploadFileReader(
 
 // GetFiles получает список файлов
 func (c *Client) GetFiles(ctx context.Context) (*FilesResponse, error) {
-	resp, err := c.makeRequest(ctx, "GET", "/files", nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return c.listFiles(ctx, FilesListOptions{})
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get files with status %d: %s", resp.StatusCode, string(body))
+// listFiles получает страницу списка файлов
+func (c *Client) listFiles(ctx context.Context, opts FilesListOptions) (*FilesResponse, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.After != "" {
+		query.Set("after", opts.After)
 	}
 
-	var files FilesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
-		return nil, fmt.Errorf("failed to decode files response: %w", err)
+	path := c.filesPath
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
 
-	return &files, nil
+	return doJSON[FilesResponse](ctx, c, "get files", "files", "GET", path, nil)
 }
 
 // GetFile получает информацию о файле
 func (c *Client) GetFile(ctx context.Context, fileID string) (*File, error) {
-	resp, err := c.makeRequest(ctx, "GET", "/files/"+fileID, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get file with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var file File
-	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
-		return nil, fmt.Errorf("failed to decode file response: %w", err)
-	}
-
-	return &file, nil
+	return doJSON[File](ctx, c, "get file", "file", "GET", c.filesPath+"/"+fileID, nil)
 }
 
 // DeleteFile удаляет файл
 func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
-	resp, err := c.makeRequest(ctx, "DELETE", "/files/"+fileID, nil)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.makeRequest(ctx, "DELETE", c.filesPath+"/"+fileID, nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete file with status %d: %s", resp.StatusCode, string(body))
+		return c.newAPIError("delete file", resp)
 	}
 
 	return nil
@@ -478,16 +1108,59 @@ func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
 
 // DownloadFile скачивает файл
 func (c *Client) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
-	resp, err := c.makeRequest(ctx, "GET", "/files/"+fileID+"/content", nil)
+	data, _, err := c.DownloadFileWithType(ctx, fileID)
+	return data, err
+}
+
+// DownloadFileWithType скачивает файл и возвращает его содержимое вместе с Content-Type
+func (c *Client) DownloadFileWithType(ctx context.Context, fileID string) ([]byte, string, error) {
+	data, _, contentType, err := c.DownloadFileInfo(ctx, fileID)
+	return data, contentType, err
+}
+
+// DownloadFileInfo скачивает файл и возвращает его содержимое, исходное имя файла
+// из заголовка Content-Disposition и Content-Type. Если сервер не передал имя файла,
+// filename пуст. Из имени отбрасываются компоненты пути, так что его можно
+// использовать при сохранении файла.
+func (c *Client) DownloadFileInfo(
+	ctx context.Context, fileID string,
+) (data []byte, filename, contentType string, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.makeRequest(ctx, "GET", c.filesPath+"/"+fileID+"/content", nil)
 	if err != nil {
-		return nil, err
+		return nil, "", "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to download file with status %d: %s", resp.StatusCode, string(body))
+		return nil, "", "", c.newAPIError("download file", resp)
+	}
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	return data, dispositionFilename(resp.Header.Get("Content-Disposition")), resp.Header.Get("Content-Type"), nil
+}
+
+// dispositionFilename возвращает имя файла из заголовка Content-Disposition без компонентов пути
+func dispositionFilename(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil || params["filename"] == "" {
+		return ""
+	}
+
+	name := filepath.Base(strings.ReplaceAll(params["filename"], "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return ""
 	}
 
-	return io.ReadAll(resp.Body)
+	return name
 }
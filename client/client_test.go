@@ -1,13 +1,25 @@
 package client
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 func TestNewClient(t *testing.T) {
 	authKey := "test_auth_key"
-	client := NewClient("Basic " + authKey)
+	client := NewClient(authKey)
 
 	if client == nil {
 		t.Fatal("NewClient returned nil")
@@ -104,6 +116,146 @@ func TestFunction(t *testing.T) {
 	}
 }
 
+func TestFunctionFewShotExamples(t *testing.T) {
+	function := Function{
+		Name: "get_weather",
+		FewShotExamples: []FewShotExample{
+			{
+				Request: "What's the weather in Moscow?",
+				Params:  map[string]any{"city": "Moscow"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(function)
+	if err != nil {
+		t.Fatalf("Failed to marshal function: %v", err)
+	}
+
+	expected := `{"name":"get_weather","few_shot_examples":[{"request":"What's the weather in Moscow?","params":{"city":"Moscow"}}]}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON to be '%s', got '%s'", expected, string(data))
+	}
+
+	data, err = json.Marshal(Function{Name: "get_weather"})
+	if err != nil {
+		t.Fatalf("Failed to marshal function: %v", err)
+	}
+
+	if string(data) != `{"name":"get_weather"}` {
+		t.Errorf("Expected few_shot_examples to be omitted, got '%s'", string(data))
+	}
+}
+
+func TestEnableImageGeneration(t *testing.T) {
+	req := &ChatRequest{Model: "GigaChat:latest"}
+
+	data, _ := json.Marshal(req)
+	if strings.Contains(string(data), "function_call") {
+		t.Errorf("Expected function_call to be omitted by default, got %s", data)
+	}
+
+	req.EnableImageGeneration()
+
+	data, _ = json.Marshal(req)
+	if !strings.Contains(string(data), `"function_call":"auto"`) {
+		t.Errorf("Expected function_call to be 'auto', got %s", data)
+	}
+}
+
+func TestNewMultimodalMessage(t *testing.T) {
+	msg := NewMultimodalMessage(RoleUser, "Что на картинке?", "file-1", "file-2")
+
+	if msg.Role != RoleUser || msg.Content != "Что на картинке?" {
+		t.Errorf("Expected user message with text, got %+v", msg)
+	}
+
+	if !slices.Equal(msg.Attachments, []string{"file-1", "file-2"}) {
+		t.Errorf("Expected attachments [file-1 file-2], got %v", msg.Attachments)
+	}
+
+	if msg := NewMultimodalMessage(RoleUser, "Привет"); msg.Attachments != nil {
+		t.Errorf("Expected no attachments, got %v", msg.Attachments)
+	}
+}
+
+func TestImageFileIDs(t *testing.T) {
+	var resp ChatResponse
+	body := `{"choices":[{"message":{"role":"assistant","content":"Here is a cat <img src=\"a1b2-c3\" fuse=\"true\"/> and a dog <img src=\"d4e5\" fuse=\"true\"/>"}}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	ids := resp.Choices[0].Message.ImageFileIDs()
+	if len(ids) != 2 || ids[0] != "a1b2-c3" || ids[1] != "d4e5" {
+		t.Errorf("Expected image IDs [a1b2-c3 d4e5], got %v", ids)
+	}
+
+	if ids := (ChatMessage{Content: "No images"}).ImageFileIDs(); ids != nil {
+		t.Errorf("Expected no image IDs, got %v", ids)
+	}
+}
+
+func TestFunctionCallUnmarshal(t *testing.T) {
+	call := FunctionCall{
+		Name:      "get_weather",
+		Arguments: map[string]any{"city": "Moscow", "days": float64(3)},
+	}
+
+	var args struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	if err := call.Unmarshal(&args); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if args.City != "Moscow" || args.Days != 3 {
+		t.Errorf("Expected city 'Moscow' and 3 days, got '%s' and %d", args.City, args.Days)
+	}
+
+	var wrong struct {
+		City int `json:"city"`
+	}
+	if err := call.Unmarshal(&wrong); err == nil {
+		t.Error("Expected error for mismatched argument type")
+	}
+}
+
+func TestDecodeMessageWithContentAndFunctionCall(t *testing.T) {
+	data := `{
+		"choices": [{
+			"index": 0,
+			"message": {
+				"role": "assistant",
+				"content": "Let me check the weather for you.",
+				"function_call": {
+					"name": "get_weather",
+					"arguments": {"city": "Moscow"}
+				}
+			}
+		}]
+	}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	msg := resp.Choices[0].Message
+	if !msg.HasContent() || msg.Content != "Let me check the weather for you." {
+		t.Errorf("Expected content to be preserved, got '%s'", msg.Content)
+	}
+
+	if !msg.HasFunctionCall() || msg.FunctionCall.Name != "get_weather" {
+		t.Fatalf("Expected function call 'get_weather', got %v", msg.FunctionCall)
+	}
+
+	if msg.FunctionCall.Arguments["city"] != "Moscow" {
+		t.Errorf("Expected city argument to be 'Moscow', got %v", msg.FunctionCall.Arguments["city"])
+	}
+}
+
 // Mock тест для проверки структуры ответов
 func TestResponseStructures(t *testing.T) {
 	// Тест структуры ChatResponse
@@ -169,6 +321,440 @@ func TestResponseStructures(t *testing.T) {
 	}
 }
 
+func TestFinishReasonDecode(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want FinishReason
+		is   func(FinishReason) bool
+	}{
+		{"stop", FinishStop, FinishReason.IsStop},
+		{"length", FinishLength, FinishReason.IsLength},
+		{"function_call", FinishFunctionCall, FinishReason.IsFunctionCall},
+		{"blacklist", FinishBlacklist, FinishReason.IsBlacklist},
+		{"error", FinishError, FinishReason.IsError},
+	}
+
+	for _, tt := range tests {
+		var choice ChatChoice
+		if err := json.Unmarshal([]byte(`{"index":0,"finish_reason":"`+tt.raw+`"}`), &choice); err != nil {
+			t.Fatalf("Failed to decode choice: %v", err)
+		}
+
+		if choice.FinishReason != tt.want {
+			t.Errorf("Expected finish reason %q, got %q", tt.want, choice.FinishReason)
+		}
+
+		if !tt.is(choice.FinishReason) {
+			t.Errorf("Expected predicate to match %q", tt.raw)
+		}
+	}
+
+	if FinishLength.IsStop() || FinishReason("").IsStop() {
+		t.Error("Expected IsStop to match only 'stop'")
+	}
+}
+
+func TestForceTokenRefresh(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(ChatResponse{})
+		})
+
+		var opts []Option
+		if force {
+			opts = append(opts, WithForceTokenRefresh())
+		}
+		client := srv.client(opts...)
+
+		for range 2 {
+			if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+				t.Fatalf("Chat failed: %v", err)
+			}
+		}
+
+		expected := int32(1)
+		if force {
+			expected = 2
+		}
+		if got := srv.authRequests.Load(); got != expected {
+			t.Errorf("Expected %d auth requests with force=%v, got %d", expected, force, got)
+		}
+	}
+}
+
+func TestAuthHeaderScheme(t *testing.T) {
+	var header string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	}, WithAuthHeaderScheme("Token"))
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if header != "Token test_token_1" {
+		t.Errorf("Expected Authorization to be 'Token test_token_1', got '%s'", header)
+	}
+
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request with invalid auth header scheme")
+	}, WithAuthHeaderScheme(""))
+
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Error("Expected error for empty auth header scheme")
+	}
+}
+
+func TestCompression(t *testing.T) {
+	var acceptEncoding string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+
+		zw := gzip.NewWriter(w)
+		json.NewEncoder(zw).Encode(ModelsResponse{
+			Data: []Model{{ID: "GigaChat", Name: "GigaChat"}},
+		})
+		zw.Close()
+	}, WithCompression())
+
+	models, err := client.GetModels(context.Background())
+	if err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if acceptEncoding != "gzip" {
+		t.Errorf("Expected Accept-Encoding to be 'gzip', got '%s'", acceptEncoding)
+	}
+
+	if len(models.Data) != 1 || models.Data[0].ID != "GigaChat" {
+		t.Errorf("Expected decoded model 'GigaChat', got %v", models.Data)
+	}
+}
+
+func TestOnTokenRefresh(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	})
+
+	var tokens []string
+	var expiry time.Time
+	client := srv.client(WithOnTokenRefresh(func(token string, exp time.Time) {
+		tokens = append(tokens, token)
+		expiry = exp
+	}))
+
+	ctx := context.Background()
+	if _, err := client.GetModels(ctx); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if err := client.GetAccessToken(ctx, GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if len(tokens) != 2 || tokens[0] != "test_token_1" || tokens[1] != "test_token_2" {
+		t.Errorf("Expected callback with tokens [test_token_1 test_token_2], got %v", tokens)
+	}
+
+	if !expiry.After(time.Now()) {
+		t.Errorf("Expected expiry in the future, got %v", expiry)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}, WithRequestTimeout(100*time.Millisecond))
+
+	// Получаем токен заранее, чтобы измерять только запрос к API
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err := client.GetModels(context.Background())
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected request to time out after ~100ms, took %s", elapsed)
+	}
+}
+
+func TestRequestEditor(t *testing.T) {
+	var signatures []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		signatures = append(signatures, r.Header.Get("X-Signature"))
+		json.NewEncoder(w).Encode(File{ID: "file-1"})
+	}, WithRequestEditor(func(r *http.Request) error {
+		r.Header.Set("X-Signature", "signed")
+		return nil
+	}))
+
+	ctx := context.Background()
+	if _, err := client.GetModels(ctx); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if _, err := client.UploadFileReader(ctx, strings.NewReader("content"), "a.txt", "text/plain", General); err != nil {
+		t.Fatalf("UploadFileReader failed: %v", err)
+	}
+
+	if len(signatures) != 2 || signatures[0] != "signed" || signatures[1] != "signed" {
+		t.Errorf("Expected both requests to be signed, got %v", signatures)
+	}
+}
+
+func TestRequestEditorError(t *testing.T) {
+	editorErr := errors.New("signing failed")
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request after editor error")
+	}, WithRequestEditor(func(r *http.Request) error {
+		return editorErr
+	}))
+
+	if _, err := client.GetModels(context.Background()); !errors.Is(err, editorErr) {
+		t.Errorf("Expected editor error, got %v", err)
+	}
+}
+
+func TestSetAuthorization(t *testing.T) {
+	var tokens []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	})
+
+	var authKeys []string
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		authKeys = append(authKeys, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: fmt.Sprintf("test_token_%d", srv.authRequests.Load()),
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	client := srv.client()
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	client.SetAuthorization("rotated_key")
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if len(authKeys) != 2 || authKeys[0] != "Basic test_auth_key" || authKeys[1] != "Basic rotated_key" {
+		t.Errorf("Expected a fresh token fetched with the rotated key, got %v", authKeys)
+	}
+
+	if len(tokens) != 2 || tokens[1] != "Bearer test_token_2" {
+		t.Errorf("Expected the second request to use the new token, got %v", tokens)
+	}
+}
+
+func TestSetAuthorizationDuringRefresh(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	var (
+		client   *Client
+		authKeys []string
+	)
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		authKeys = append(authKeys, r.Header.Get("Authorization"))
+		if len(authKeys) == 1 {
+			// Ключ меняется, пока запрос токена со старым ключом еще выполняется
+			client.SetAuthorization("rotated_key")
+		}
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: fmt.Sprintf("test_token_%d", len(authKeys)),
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	client = srv.client()
+
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if len(authKeys) != 2 || authKeys[1] != "Basic rotated_key" {
+		t.Errorf("Expected the token to be fetched again with the rotated key, got %v", authKeys)
+	}
+
+	if token, _ := client.token(); token != "test_token_2" {
+		t.Errorf("Expected token of the rotated key to be stored, got '%s'", token)
+	}
+}
+
+func TestRequestIDGenerator(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	var rqUID string
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		rqUID = r.Header.Get("RqUID")
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "test_token",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	client := srv.client(WithRequestIDGenerator(func() string {
+		return "fixed-request-id"
+	}))
+
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if rqUID != "fixed-request-id" {
+		t.Errorf("Expected RqUID to be 'fixed-request-id', got '%s'", rqUID)
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	var ids []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		json.NewEncoder(w).Encode(ChatResponse{})
+	})
+
+	ctx := WithResponseCapture(context.Background())
+	for range 2 {
+		if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+
+	if len(ids) != 2 || ids[0] == "" || ids[1] == "" {
+		t.Fatalf("Expected both requests to carry X-Request-ID, got %v", ids)
+	}
+
+	if ids[0] == ids[1] {
+		t.Errorf("Expected distinct request IDs, got '%s' twice", ids[0])
+	}
+
+	if got := ResponseRequestID(ctx); got != ids[1] {
+		t.Errorf("Expected captured request ID to fall back to '%s', got '%s'", ids[1], got)
+	}
+}
+
+func TestChatPath(t *testing.T) {
+	var path string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewEncoder(w).Encode(ChatResponse{})
+	}, WithChatPath("/gateway/chat"))
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if path != "/gateway/chat" {
+		t.Errorf("Expected path to be '/gateway/chat', got '%s'", path)
+	}
+}
+
+func TestBaseURLTrailingSlash(t *testing.T) {
+	var paths []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	})
+	client := srv.client(WithBaseURL(srv.URL+"/api/v1/"), WithAuthURL(srv.URL+"/oauth/"))
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if len(paths) != 1 || paths[0] != "/api/v1/models" {
+		t.Errorf("Expected path '/api/v1/models', got %v", paths)
+	}
+
+	if srv.authRequests.Load() != 1 {
+		t.Errorf("Expected token to be fetched from '/oauth', got %d auth requests", srv.authRequests.Load())
+	}
+}
+
+func TestInvalidPathOption(t *testing.T) {
+	for _, path := range []string{"", "files"} {
+		client := NewClient("key", WithFilesPath(path))
+		if _, err := client.GetFiles(context.Background()); err == nil {
+			t.Errorf("Expected config error for path %q", path)
+		}
+	}
+}
+
+func TestResponseHeaderCallback(t *testing.T) {
+	var (
+		gotPath   string
+		gotHeader http.Header
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-Request-ID", "req-1")
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	}, WithResponseHeaderCallback(func(path string, h http.Header) {
+		gotPath = path
+		gotHeader = h
+	}))
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if gotPath != "/models" {
+		t.Errorf("Expected path to be '/models', got '%s'", gotPath)
+	}
+
+	if gotHeader.Get("X-RateLimit-Remaining") != "42" || gotHeader.Get("X-Request-ID") != "req-1" {
+		t.Errorf("Expected rate limit and request ID headers, got %v", gotHeader)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var times []time.Time
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		w.Write([]byte(`{"data":[]}`))
+	}, WithRateLimiter(rate.NewLimiter(rate.Every(50*time.Millisecond), 1)))
+
+	for range 3 {
+		if _, err := client.GetModels(context.Background()); err != nil {
+			t.Fatalf("GetModels failed: %v", err)
+		}
+	}
+
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("Expected requests to be paced at least 40ms apart, got %s between %d and %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestRateLimiterContext(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	limiter.Allow()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request while waiting for the limiter")
+	}, WithRateLimiter(limiter))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetModels(ctx); err == nil {
+		t.Error("Expected error when context expires while waiting for the limiter")
+	}
+}
+
 // Интеграционный тест (требует реальных credentials)
 func TestIntegrationWithMock(t *testing.T) {
 	// Этот тест можно запускать только с реальными credentials
@@ -187,3 +773,195 @@ func TestIntegrationWithMock(t *testing.T) {
 		t.Logf("Expected error without real credentials: %v", err)
 	}
 }
+
+// testServer представляет тестовый сервер API с mock авторизацией
+type testServer struct {
+	*httptest.Server
+
+	// authRequests считает запросы на получение токена
+	authRequests atomic.Int32
+	// authHandler, если задан, заменяет стандартный ответ авторизации
+	authHandler http.HandlerFunc
+}
+
+// newTestServer запускает тестовый сервер, передающий запросы к API в handler
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	t.Helper()
+
+	srv := &testServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth", func(w http.ResponseWriter, r *http.Request) {
+		srv.authRequests.Add(1)
+		if srv.authHandler != nil {
+			srv.authHandler(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: fmt.Sprintf("test_token_%d", srv.authRequests.Load()),
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	})
+	mux.HandleFunc("/", handler)
+
+	srv.Server = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// client создает клиент, направленный на тестовый сервер
+func (s *testServer) client(opts ...Option) *Client {
+	opts = append([]Option{WithBaseURL(s.URL), WithAuthURL(s.URL + "/oauth")}, opts...)
+	return NewClient("test_auth_key", opts...)
+}
+
+// newTestClient создает клиент, направленный на тестовый сервер с mock авторизацией
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	return newTestServer(t, handler).client(opts...)
+}
+
+func TestMethodOverride(t *testing.T) {
+	var method, override, path string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		override = r.Header.Get("X-HTTP-Method-Override")
+		path = r.URL.Path
+		w.Write([]byte(`{}`))
+	}, WithMethodOverride())
+
+	if err := client.DeleteFile(context.Background(), "file-1"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+
+	if method != http.MethodPost || override != http.MethodDelete || path != "/files/file-1" {
+		t.Errorf("Expected POST /files/file-1 with DELETE override, got %s %s with override '%s'", method, path, override)
+	}
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if method != http.MethodPost || override != "" {
+		t.Errorf("Expected POST without override for chat, got %s with override '%s'", method, override)
+	}
+}
+
+func TestMethodOverrideRetry(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, unavailableHandler(&requests),
+		WithMethodOverride(),
+		WithMaxRetries(1),
+		WithRetryBackoff(time.Millisecond),
+	)
+
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected overridden GET to be retried, got %d attempts", requests.Load())
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	client := NewClient("key", WithRegion(RegionPreview))
+
+	if client.baseURL != "https://gigachat-preview.devices.sberbank.ru/api/v1" {
+		t.Errorf("Expected preview baseURL, got '%s'", client.baseURL)
+	}
+
+	if client.authURL != "https://ngw.devices.sberbank.ru:9443/api/v2/oauth" {
+		t.Errorf("Expected preview authURL, got '%s'", client.authURL)
+	}
+
+	defaults := NewClient("key")
+	client = NewClient("key", WithBaseURL("https://example.com"), WithRegion(RegionRU))
+	if client.baseURL != defaults.baseURL || client.authURL != defaults.authURL {
+		t.Errorf("Expected RegionRU to match default URLs, got '%s' and '%s'", client.baseURL, client.authURL)
+	}
+
+	client = NewClient("key", WithRegion("mars"))
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Error("Expected error for unknown region")
+	}
+}
+
+func TestDoJSONMethods(t *testing.T) {
+	tests := []struct {
+		name      string
+		op        string
+		decodeErr string
+		body      string
+		call      func(c *Client) (any, error)
+		want      func(v any) bool
+	}{
+		{
+			name: "GetModels", op: "get models", decodeErr: "failed to decode models response: ",
+			body: `{"object":"list","data":[{"id":"GigaChat"}]}`,
+			call: func(c *Client) (any, error) { return c.GetModels(context.Background()) },
+			want: func(v any) bool { return v.(*ModelsResponse).Data[0].ID == "GigaChat" },
+		},
+		{
+			name: "Chat", op: "chat", decodeErr: "failed to decode chat response: ",
+			body: `{"id":"chat-1","choices":[{"message":{"role":"assistant","content":"Hi"}}]}`,
+			call: func(c *Client) (any, error) {
+				return c.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+			},
+			want: func(v any) bool { return v.(*ChatResponse).Choices[0].Message.Content == "Hi" },
+		},
+		{
+			name: "CreateEmbeddings", op: "create embeddings", decodeErr: "failed to decode embeddings response: ",
+			body: `{"object":"list","data":[{"embedding":[1.5],"index":0}]}`,
+			call: func(c *Client) (any, error) {
+				return c.CreateEmbeddings(context.Background(), &EmbeddingRequest{Model: "Embeddings", Input: []string{"a"}})
+			},
+			want: func(v any) bool { return v.(*EmbeddingResponse).Data[0].Embedding[0] == 1.5 },
+		},
+		{
+			name: "GetFiles", op: "get files", decodeErr: "failed to decode files response: ",
+			body: `{"data":[{"id":"file-1"}]}`,
+			call: func(c *Client) (any, error) { return c.GetFiles(context.Background()) },
+			want: func(v any) bool { return v.(*FilesResponse).Data[0].ID == "file-1" },
+		},
+		{
+			name: "GetFile", op: "get file", decodeErr: "failed to decode file response: ",
+			body: `{"id":"file-1"}`,
+			call: func(c *Client) (any, error) { return c.GetFile(context.Background(), "file-1") },
+			want: func(v any) bool { return v.(*File).ID == "file-1" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := http.StatusOK, tt.body
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+				w.Write([]byte(body))
+			})
+
+			v, err := tt.call(client)
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+			if !tt.want(v) {
+				t.Errorf("Unexpected decoded response %+v", v)
+			}
+
+			status, body = http.StatusNotFound, `{"status":404,"message":"Not found"}`
+			_, err = tt.call(client)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Op != tt.op || apiErr.StatusCode != http.StatusNotFound {
+				t.Errorf("Expected APIError for '%s' with status 404, got %v", tt.op, err)
+			}
+
+			status, body = http.StatusOK, `{`
+			if _, err = tt.call(client); err == nil || !strings.HasPrefix(err.Error(), tt.decodeErr) {
+				t.Errorf("Expected decode error '%s...', got %v", tt.decodeErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+type contextKey int
+
+const (
+	tagsKey contextKey = iota
+	responseInfoKey
+	sessionIDKey
+	retryCountKey
+	forceRefreshKey
+	tokensPerSecondKey
+)
+
+// WithTags добавляет к запросам с этим контекстом теги, которые попадают в логи клиента.
+// Теги объединяются с уже добавленными ранее.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := maps.Clone(tagsFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+
+	return context.WithValue(ctx, tagsKey, merged)
+}
+
+// tagsFromContext возвращает теги, добавленные через WithTags
+func tagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	return tags
+}
+
+// tagsAttr возвращает теги контекста в виде группы атрибутов лога
+func tagsAttr(ctx context.Context) (slog.Attr, bool) {
+	tags := tagsFromContext(ctx)
+	if len(tags) == 0 {
+		return slog.Attr{}, false
+	}
+
+	attrs := make([]any, 0, len(tags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		attrs = append(attrs, slog.String(k, tags[k]))
+	}
+
+	return slog.Group("tags", attrs...), true
+}
+
+// WithSessionID задает идентификатор сессии (заголовок X-Session-ID) для запросов с этим контекстом.
+// GigaChat использует его для кэширования контекста диалога между запросами.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// sessionIDFromContext возвращает идентификатор сессии, заданный через WithSessionID
+func sessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionIDKey).(string)
+	return sessionID
+}
+
+// WithRetryCount переопределяет для запросов с этим контекстом число повторов,
+// заданное WithMaxRetries. Отрицательное n не переопределяет настройку клиента.
+func WithRetryCount(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, retryCountKey, n)
+}
+
+// retryCountFromContext возвращает число повторов, заданное через WithRetryCount
+func retryCountFromContext(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(retryCountKey).(int)
+	if !ok || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// WithForceRefresh заставляет клиент получить новый токен перед отправкой запросов с этим контекстом,
+// не отключая кэширование токена для остальных запросов
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey, true)
+}
+
+// forceRefreshFromContext сообщает, запрошено ли обновление токена через WithForceRefresh
+func forceRefreshFromContext(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey).(bool)
+	return force
+}
+
+// WithDeadlineMaxTokens ограничивает MaxTokens запросов на чат с этим контекстом так,
+// чтобы генерация со скоростью tokensPerSecond успела завершиться до дедлайна ctx.
+// Без дедлайна или с неположительной скоростью MaxTokens не меняется.
+func WithDeadlineMaxTokens(ctx context.Context, tokensPerSecond float64) context.Context {
+	return context.WithValue(ctx, tokensPerSecondKey, tokensPerSecond)
+}
+
+// DeadlineMaxTokens возвращает число токенов, которое модель успеет сгенерировать
+// со скоростью tokensPerSecond до дедлайна ctx, но не меньше 1.
+// Если у ctx нет дедлайна, возвращается false.
+func DeadlineMaxTokens(ctx context.Context, tokensPerSecond float64) (int, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok || tokensPerSecond <= 0 {
+		return 0, false
+	}
+
+	return max(int(time.Until(deadline).Seconds()*tokensPerSecond), 1), true
+}
+
+// capMaxTokens уменьшает MaxTokens запроса до бюджета, заданного через WithDeadlineMaxTokens
+func capMaxTokens(ctx context.Context, req *ChatRequest) {
+	tokensPerSecond, _ := ctx.Value(tokensPerSecondKey).(float64)
+	limit, ok := DeadlineMaxTokens(ctx, tokensPerSecond)
+	if !ok || (req.MaxTokens != nil && *req.MaxTokens <= limit) {
+		return
+	}
+	req.MaxTokens = &limit
+}
+
+// responseInfo хранит сведения о последнем ответе API, полученном с контекстом
+type responseInfo struct {
+	mu        sync.Mutex
+	requestID string
+	sessionID string
+}
+
+// WithResponseCapture возвращает контекст, в котором клиент сохраняет сведения
+// о полученных ответах. Они доступны через ResponseRequestID и ResponseSessionID.
+func WithResponseCapture(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseInfoKey, &responseInfo{})
+}
+
+// ResponseRequestID возвращает идентификатор запроса (заголовок X-Request-ID)
+// последнего ответа, полученного с контекстом, подготовленным WithResponseCapture.
+// Если сервер не вернул заголовок, возвращается идентификатор, отправленный клиентом.
+func ResponseRequestID(ctx context.Context) string {
+	info, ok := ctx.Value(responseInfoKey).(*responseInfo)
+	if !ok {
+		return ""
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+
+	return info.requestID
+}
+
+// ResponseSessionID возвращает идентификатор сессии (заголовок X-Session-ID) последнего
+// ответа, полученного с контекстом, подготовленным WithResponseCapture.
+// Если сервер не вернул заголовок, возвращается пустая строка.
+func ResponseSessionID(ctx context.Context) string {
+	info, ok := ctx.Value(responseInfoKey).(*responseInfo)
+	if !ok {
+		return ""
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+
+	return info.sessionID
+}
+
+// captureResponse сохраняет сведения об ответе в контексте, если это запрошено
+func captureResponse(ctx context.Context, resp *http.Response) {
+	info, ok := ctx.Value(responseInfoKey).(*responseInfo)
+	if !ok {
+		return
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+
+	info.requestID = resp.Header.Get("X-Request-ID")
+	info.sessionID = resp.Header.Get("X-Session-ID")
+	if info.requestID == "" && resp.Request != nil {
+		info.requestID = resp.Request.Header.Get("X-Request-ID")
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithTagsLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{})
+	}, WithLogger(logger))
+
+	ctx := WithTags(context.Background(), map[string]string{"user_id": "42"})
+	ctx = WithTags(ctx, map[string]string{"feature": "beta"})
+
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	var record struct {
+		Msg    string            `json:"msg"`
+		Path   string            `json:"path"`
+		Status int               `json:"status"`
+		Tags   map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode log record %q: %v", buf.String(), err)
+	}
+
+	if record.Path != "/chat/completions" {
+		t.Errorf("Expected path to be '/chat/completions', got '%s'", record.Path)
+	}
+
+	if record.Status != http.StatusOK {
+		t.Errorf("Expected status to be 200, got %d", record.Status)
+	}
+
+	if record.Tags["user_id"] != "42" || record.Tags["feature"] != "beta" {
+		t.Errorf("Expected tags user_id=42 and feature=beta, got %v", record.Tags)
+	}
+}
+
+func TestResponseRequestID(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-123")
+		json.NewEncoder(w).Encode(ChatResponse{})
+	})
+
+	ctx := WithResponseCapture(context.Background())
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if id := ResponseRequestID(ctx); id != "req-123" {
+		t.Errorf("Expected request ID to be 'req-123', got '%s'", id)
+	}
+
+	if id := ResponseRequestID(context.Background()); id != "" {
+		t.Errorf("Expected empty request ID without capture, got '%s'", id)
+	}
+}
+
+func TestWithSessionID(t *testing.T) {
+	var sessionID string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sessionID = r.Header.Get("X-Session-ID")
+		json.NewEncoder(w).Encode(ChatResponse{})
+	})
+
+	ctx := WithSessionID(context.Background(), "session-1")
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if sessionID != "session-1" {
+		t.Errorf("Expected X-Session-ID to be 'session-1', got '%s'", sessionID)
+	}
+}
+
+func TestWithForceRefresh(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{})
+	})
+	client := server.client()
+	req := &ChatRequest{Model: "GigaChat:latest"}
+
+	if _, err := client.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, err := client.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if got := server.authRequests.Load(); got != 1 {
+		t.Fatalf("Expected unflagged requests to reuse the cached token, got %d auth requests", got)
+	}
+
+	if _, err := client.Chat(WithForceRefresh(context.Background()), req); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if got := server.authRequests.Load(); got != 2 {
+		t.Errorf("Expected flagged request to fetch a new token, got %d auth requests", got)
+	}
+}
+
+func TestWithDeadlineMaxTokens(t *testing.T) {
+	var requests []ChatRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(ChatResponse{})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ctx = WithDeadlineMaxTokens(ctx, 50)
+
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest", MaxTokens: ptr(1000)}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest", MaxTokens: ptr(10)}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, err := client.Chat(WithDeadlineMaxTokens(context.Background(), 50), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if got := requests[0].MaxTokens; got == nil || *got > 100 || *got < 1 {
+		t.Errorf("Expected MaxTokens to be capped to at most 100 near the deadline, got %v", got)
+	}
+
+	if got := requests[1].MaxTokens; got == nil || *got != 10 {
+		t.Errorf("Expected MaxTokens within budget to be kept at 10, got %v", got)
+	}
+
+	if requests[2].MaxTokens != nil {
+		t.Errorf("Expected MaxTokens to be unset without deadline, got %d", *requests[2].MaxTokens)
+	}
+}
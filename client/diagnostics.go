@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// CheckAuthEndpoint проверяет доступность сервера авторизации. Отправляется запрос HEAD
+// без ключа авторизации: сервер считается доступным, если вернул любой HTTP ответ.
+func (c *Client) CheckAuthEndpoint(ctx context.Context) error {
+	return c.checkEndpoint(ctx, "auth", c.authURL)
+}
+
+// CheckAPIEndpoint проверяет доступность API так же, как CheckAuthEndpoint,
+// не получая токен доступа
+func (c *Client) CheckAPIEndpoint(ctx context.Context) error {
+	return c.checkEndpoint(ctx, "API", c.baseURL)
+}
+
+// checkEndpoint отправляет запрос HEAD на endpoint и сообщает, получен ли ответ
+func (c *Client) checkEndpoint(ctx context.Context, name, endpoint string) error {
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid %s endpoint %s: %w", name, endpoint, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s endpoint %s is unreachable: %w", name, endpoint, err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckEndpoints(t *testing.T) {
+	var authHits, apiHits []string
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHits = append(authHits, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer auth.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiHits = append(apiHits, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Expected probe without Authorization header")
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	client := NewClient("key", WithAuthURL(auth.URL+"/oauth"), WithBaseURL(api.URL+"/api/v1"))
+
+	if err := client.CheckAuthEndpoint(context.Background()); err != nil {
+		t.Errorf("CheckAuthEndpoint failed: %v", err)
+	}
+	if err := client.CheckAPIEndpoint(context.Background()); err != nil {
+		t.Errorf("CheckAPIEndpoint failed: %v", err)
+	}
+
+	if len(authHits) != 1 || authHits[0] != "HEAD /oauth" {
+		t.Errorf("Expected one HEAD /oauth probe on auth host, got %v", authHits)
+	}
+
+	if len(apiHits) != 1 || apiHits[0] != "HEAD /api/v1" {
+		t.Errorf("Expected one HEAD /api/v1 probe on API host, got %v", apiHits)
+	}
+}
+
+func TestCheckEndpointsUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	client := NewClient("key", WithAuthURL(url+"/oauth"), WithBaseURL(url+"/api/v1"))
+
+	err := client.CheckAuthEndpoint(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "auth endpoint") || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Expected unreachable auth endpoint error, got %v", err)
+	}
+
+	err = client.CheckAPIEndpoint(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "API endpoint") || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Expected unreachable API endpoint error, got %v", err)
+	}
+}
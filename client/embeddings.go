@@ -0,0 +1,307 @@
+package client
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+)
+
+// CosineSimilarity возвращает косинусное сходство векторов a и b: 1 для сонаправленных,
+// 0 для ортогональных. Векторы должны быть одной длины и ненулевыми.
+func CosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors have different lengths: %d and %d", len(a), len(b))
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0, fmt.Errorf("cosine similarity is undefined for zero vectors")
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// SimilarityTo возвращает косинусное сходство эмбеддинга с other
+func (e Embedding) SimilarityTo(other Embedding) (float64, error) {
+	return CosineSimilarity(e.Embedding, other.Embedding)
+}
+
+// sortEmbeddings упорядочивает эмбеддинги по Index, чтобы они соответствовали
+// порядку входных текстов, и проверяет, что каждому из n текстов
+// соответствует ровно один эмбеддинг
+func sortEmbeddings(data []Embedding, n int) error {
+	seen := make([]bool, n)
+	for _, emb := range data {
+		if emb.Index < 0 || emb.Index >= n {
+			return fmt.Errorf("embedding index %d out of range for %d inputs", emb.Index, n)
+		}
+		if seen[emb.Index] {
+			return fmt.Errorf("duplicate embedding index %d", emb.Index)
+		}
+		seen[emb.Index] = true
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			return fmt.Errorf("missing embedding index %d", i)
+		}
+	}
+
+	slices.SortFunc(data, func(a, b Embedding) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	return nil
+}
+
+// fillPromptTokensPerInput заполняет PromptTokensPerInput из использования токенов отдельных эмбеддингов
+func (r *EmbeddingResponse) fillPromptTokensPerInput() {
+	r.PromptTokensPerInput = nil
+
+	tokens := make([]int, len(r.Data))
+	for i, emb := range r.Data {
+		if emb.Usage == nil {
+			return
+		}
+		tokens[i] = emb.Usage.PromptTokens
+	}
+
+	if len(tokens) > 0 {
+		r.PromptTokensPerInput = tokens
+	}
+}
+
+// createEmbeddingsCached возвращает эмбеддинги из кэша и запрашивает у API только недостающие
+func (c *Client) createEmbeddingsCached(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	result := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]Embedding, len(req.Input)),
+	}
+
+	var missing []int
+	for i, input := range req.Input {
+		vector, ok := c.embeddingCache.get(embeddingCacheKey{model: req.Model, input: input})
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		result.Data[i] = Embedding{Object: "embedding", Embedding: vector, Index: i}
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	freshReq := *req
+	freshReq.Input = make([]string, len(missing))
+	for j, i := range missing {
+		freshReq.Input[j] = req.Input[i]
+	}
+
+	fresh, err := c.createEmbeddings(ctx, &freshReq)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, emb := range fresh.Data {
+		i := missing[j]
+		c.embeddingCache.add(embeddingCacheKey{model: req.Model, input: req.Input[i]}, emb.Embedding)
+		emb.Index = i
+		result.Data[i] = emb
+	}
+	result.Object = fresh.Object
+	result.Usage = fresh.Usage
+	result.fillPromptTokensPerInput()
+
+	return result, nil
+}
+
+// CreateEmbeddingsBatched создает эмбеддинги, разбивая входные тексты на запросы
+// не более чем по batchSize текстов. При отмене ctx оставшиеся пакеты не отправляются,
+// а метод возвращает уже полученные эмбеддинги вместе с ctx.Err().
+// С WithBatchConcurrency пакеты отправляются параллельно, порядок результата сохраняется.
+func (c *Client) CreateEmbeddingsBatched(
+	ctx context.Context, req *EmbeddingRequest, batchSize int,
+) (*EmbeddingResponse, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+
+	var starts []int
+	for start := 0; start < len(req.Input); start += batchSize {
+		starts = append(starts, start)
+	}
+
+	batch := func(start int) *EmbeddingRequest {
+		b := *req
+		b.Input = req.Input[start:min(start+batchSize, len(req.Input))]
+		return &b
+	}
+
+	if c.batchConcurrency > 1 && len(starts) > 1 {
+		return c.createEmbeddingsConcurrent(ctx, starts, batch)
+	}
+
+	result := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]Embedding, 0, len(req.Input)),
+	}
+
+	for _, start := range starts {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		resp, err := c.CreateEmbeddings(ctx, batch(start))
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return result, ctxErr
+			}
+			return result, fmt.Errorf("failed to create embeddings batch at input %d: %w", start, err)
+		}
+
+		result.appendBatch(resp, start)
+	}
+
+	return result, nil
+}
+
+// createEmbeddingsConcurrent отправляет пакеты не более чем в c.batchConcurrency потоков.
+// После первой ошибки оставшиеся пакеты не отправляются, а в результат попадают
+// все успешно полученные пакеты в порядке входных текстов.
+func (c *Client) createEmbeddingsConcurrent(
+	ctx context.Context, starts []int, batch func(start int) *EmbeddingRequest,
+) (*EmbeddingResponse, error) {
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		responses = make([]*EmbeddingResponse, len(starts))
+		jobs      = make(chan int)
+		wg        sync.WaitGroup
+		errOnce   sync.Once
+		firstErr  error
+	)
+
+	for range min(c.batchConcurrency, len(starts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				resp, err := c.CreateEmbeddings(batchCtx, batch(starts[i]))
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("failed to create embeddings batch at input %d: %w", starts[i], err)
+						cancel()
+					})
+					continue
+				}
+				responses[i] = resp
+			}
+		}()
+	}
+
+send:
+	for i := range starts {
+		select {
+		case jobs <- i:
+		case <-batchCtx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &EmbeddingResponse{Object: "list"}
+	for i, resp := range responses {
+		if resp != nil {
+			result.appendBatch(resp, starts[i])
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	return result, firstErr
+}
+
+// appendBatch добавляет к результату эмбеддинги пакета, начинающегося с входного текста start
+func (r *EmbeddingResponse) appendBatch(resp *EmbeddingResponse, start int) {
+	for _, emb := range resp.Data {
+		emb.Index += start
+		r.Data = append(r.Data, emb)
+	}
+	r.Usage = r.Usage.Add(resp.Usage)
+	r.fillPromptTokensPerInput()
+}
+
+type embeddingCacheKey struct {
+	model string
+	input string
+}
+
+type embeddingCacheEntry struct {
+	key    embeddingCacheKey
+	vector []float64
+}
+
+// embeddingCache представляет потокобезопасный LRU-кэш эмбеддингов
+type embeddingCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[embeddingCacheKey]*list.Element
+}
+
+func newEmbeddingCache(size int) *embeddingCache {
+	return &embeddingCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[embeddingCacheKey]*list.Element),
+	}
+}
+
+// get возвращает копию закэшированного вектора
+func (c *embeddingCache) get(key embeddingCacheKey) ([]float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return slices.Clone(elem.Value.(*embeddingCacheEntry).vector), true
+}
+
+// add сохраняет копию вектора, вытесняя самую старую запись при переполнении
+func (c *embeddingCache) add(key embeddingCacheKey, vector []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*embeddingCacheEntry).vector = slices.Clone(vector)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&embeddingCacheEntry{key: key, vector: slices.Clone(vector)})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*embeddingCacheEntry).key)
+	}
+}
@@ -0,0 +1,404 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateEmbeddingsOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		indices []int
+		wantErr string
+	}{
+		{name: "shuffled", indices: []int{2, 0, 1}},
+		{name: "gap", indices: []int{0, 2, 3}, wantErr: "out of range"},
+		{name: "missing", indices: []int{2, 0}, wantErr: "missing embedding index 1"},
+		{name: "duplicate", indices: []int{0, 0, 1}, wantErr: "duplicate embedding index 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				resp := EmbeddingResponse{Object: "list"}
+				for _, idx := range tt.indices {
+					resp.Data = append(resp.Data, Embedding{
+						Object:    "embedding",
+						Embedding: []float64{float64(idx)},
+						Index:     idx,
+					})
+				}
+				json.NewEncoder(w).Encode(resp)
+			})
+
+			resp, err := client.CreateEmbeddings(context.Background(), &EmbeddingRequest{
+				Model: "Embeddings",
+				Input: []string{"a", "b", "c"},
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing '%s', got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CreateEmbeddings failed: %v", err)
+			}
+
+			for i, emb := range resp.Data {
+				if emb.Index != i || emb.Embedding[0] != float64(i) {
+					t.Errorf("Expected embedding %d at position %d, got index %d", i, i, emb.Index)
+				}
+			}
+		})
+	}
+}
+
+// lengthEmbeddingHandler возвращает для каждого входного текста вектор из его длины
+func lengthEmbeddingHandler(t *testing.T, requests *atomic.Int32, inputs *[][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		requests.Add(1)
+		if inputs != nil {
+			*inputs = append(*inputs, req.Input)
+		}
+
+		resp := EmbeddingResponse{Object: "list"}
+		for i, input := range req.Input {
+			resp.Data = append(resp.Data, Embedding{
+				Object:    "embedding",
+				Embedding: []float64{float64(len(input))},
+				Index:     i,
+			})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func TestEmbeddingCache(t *testing.T) {
+	var requests atomic.Int32
+	var inputs [][]string
+	client := newTestClient(t, lengthEmbeddingHandler(t, &requests, &inputs), WithEmbeddingCache(10))
+
+	ctx := context.Background()
+	req := &EmbeddingRequest{Model: "Embeddings", Input: []string{"a", "bb"}}
+
+	if _, err := client.CreateEmbeddings(ctx, req); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected identical call to be served from cache, got %d requests", requests.Load())
+	}
+
+	if len(resp.Data) != 2 || resp.Data[1].Embedding[0] != 2 {
+		t.Errorf("Expected cached embeddings for both inputs, got %v", resp.Data)
+	}
+
+	resp, err = client.CreateEmbeddings(ctx, &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"ccc", "a", "dddd"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	if requests.Load() != 2 {
+		t.Fatalf("Expected one request for uncached inputs, got %d requests", requests.Load())
+	}
+
+	if strings.Join(inputs[1], ",") != "ccc,dddd" {
+		t.Errorf("Expected only uncached inputs to be requested, got %v", inputs[1])
+	}
+
+	for i, expected := range []float64{3, 1, 4} {
+		if resp.Data[i].Index != i || resp.Data[i].Embedding[0] != expected {
+			t.Errorf("Expected embedding %v at index %d, got %v", expected, i, resp.Data[i])
+		}
+	}
+}
+
+func TestEmbeddingCacheEviction(t *testing.T) {
+	cache := newEmbeddingCache(2)
+	for _, input := range []string{"a", "b", "c"} {
+		cache.add(embeddingCacheKey{model: "Embeddings", input: input}, []float64{1})
+	}
+
+	if _, ok := cache.get(embeddingCacheKey{model: "Embeddings", input: "a"}); ok {
+		t.Error("Expected oldest entry to be evicted")
+	}
+
+	if _, ok := cache.get(embeddingCacheKey{model: "Embeddings", input: "c"}); !ok {
+		t.Error("Expected newest entry to be cached")
+	}
+}
+
+func TestCreateEmbeddingsBatched(t *testing.T) {
+	var requests atomic.Int32
+	var inputs [][]string
+	client := newTestClient(t, lengthEmbeddingHandler(t, &requests, &inputs))
+
+	resp, err := client.CreateEmbeddingsBatched(context.Background(), &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"a", "bb", "ccc", "dddd", "eeeee"},
+	}, 2)
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsBatched failed: %v", err)
+	}
+
+	if len(inputs) != 3 {
+		t.Fatalf("Expected 3 batch requests, got %d", len(inputs))
+	}
+
+	for i, emb := range resp.Data {
+		if emb.Index != i || emb.Embedding[0] != float64(i+1) {
+			t.Errorf("Expected embedding %d to have index %d and value %d, got index %d and value %v",
+				i, i, i+1, emb.Index, emb.Embedding)
+		}
+	}
+}
+
+func TestCreateEmbeddingsBatchedCancel(t *testing.T) {
+	var requests atomic.Int32
+	srv := newTestServer(t, lengthEmbeddingHandler(t, &requests, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Отменяем контекст сразу после получения ответа на первый пакет
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil || r.URL.Path != "/embeddings" {
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		cancel()
+
+		return resp, nil
+	})
+	client := srv.client(WithHTTPClient(&http.Client{Transport: transport}))
+
+	resp, err := client.CreateEmbeddingsBatched(ctx, &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"a", "bb", "ccc", "dddd"},
+	}, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected no batch request after cancellation, got %d requests", got)
+	}
+
+	if resp == nil || len(resp.Data) != 2 {
+		t.Fatalf("Expected partial result with 2 embeddings, got %+v", resp)
+	}
+}
+
+func TestCreateEmbeddingsBatchedConcurrency(t *testing.T) {
+	var requests, inFlight, maxInFlight atomic.Int32
+	handler := lengthEmbeddingHandler(t, &requests, nil)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+
+		// Задержка позволяет пакетам выполняться одновременно
+		time.Sleep(20 * time.Millisecond)
+		handler(w, r)
+	}, WithBatchConcurrency(3))
+
+	resp, err := client.CreateEmbeddingsBatched(context.Background(), &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"a", "bb", "ccc", "dddd", "eeeee"},
+	}, 1)
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsBatched failed: %v", err)
+	}
+
+	if requests.Load() != 5 {
+		t.Errorf("Expected 5 batch requests, got %d", requests.Load())
+	}
+
+	if got := maxInFlight.Load(); got > 3 {
+		t.Errorf("Expected at most 3 concurrent requests, got %d", got)
+	}
+
+	if len(resp.Data) != 5 {
+		t.Fatalf("Expected 5 embeddings, got %d", len(resp.Data))
+	}
+
+	for i, emb := range resp.Data {
+		if emb.Index != i || emb.Embedding[0] != float64(i+1) {
+			t.Errorf("Expected embedding %d to have index %d and value %d, got index %d and value %v",
+				i, i, i+1, emb.Index, emb.Embedding)
+		}
+	}
+}
+
+func TestInvalidBatchConcurrency(t *testing.T) {
+	client := NewClient("test_auth_key", WithBatchConcurrency(0))
+	if _, err := client.CreateEmbeddingsBatched(context.Background(), &EmbeddingRequest{Input: []string{"a"}}, 1); err == nil {
+		t.Error("Expected error for zero batch concurrency")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCreateEmbedding(t *testing.T) {
+	var requests atomic.Int32
+	var inputs [][]string
+	client := newTestClient(t, lengthEmbeddingHandler(t, &requests, &inputs))
+
+	emb, err := client.CreateEmbedding(context.Background(), "Embeddings", "hello")
+	if err != nil {
+		t.Fatalf("CreateEmbedding failed: %v", err)
+	}
+
+	if len(inputs) != 1 || len(inputs[0]) != 1 || inputs[0][0] != "hello" {
+		t.Errorf("Expected single input 'hello', got %v", inputs)
+	}
+
+	if emb.Index != 0 || len(emb.Embedding) != 1 || emb.Embedding[0] != 5 {
+		t.Errorf("Expected embedding [5] at index 0, got %+v", emb)
+	}
+}
+
+func TestEmbeddingsPromptTokensPerInput(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"object": "list",
+			"data": [
+				{"object": "embedding", "embedding": [0.2], "index": 1, "usage": {"prompt_tokens": 7}},
+				{"object": "embedding", "embedding": [0.1], "index": 0, "usage": {"prompt_tokens": 3}}
+			],
+			"model": "Embeddings"
+		}`))
+	})
+
+	resp, err := client.CreateEmbeddings(context.Background(), &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"short", "a longer text"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	if len(resp.PromptTokensPerInput) != 2 || resp.PromptTokensPerInput[0] != 3 || resp.PromptTokensPerInput[1] != 7 {
+		t.Errorf("Expected per-input tokens [3 7], got %v", resp.PromptTokensPerInput)
+	}
+}
+
+func TestEmbeddingsWithoutPerInputUsage(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, lengthEmbeddingHandler(t, &requests, nil))
+
+	resp, err := client.CreateEmbeddings(context.Background(), &EmbeddingRequest{
+		Model: "Embeddings",
+		Input: []string{"a"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	if resp.PromptTokensPerInput != nil {
+		t.Errorf("Expected no per-input tokens, got %v", resp.PromptTokensPerInput)
+	}
+
+	data, _ := json.Marshal(resp.Data[0])
+	if strings.Contains(string(data), "usage") {
+		t.Errorf("Expected usage to be omitted, got %s", data)
+	}
+}
+
+func TestEmbeddingWithoutVector(t *testing.T) {
+	var emb Embedding
+	if err := json.Unmarshal([]byte(`{"object":"embedding","index":2}`), &emb); err != nil {
+		t.Fatalf("Failed to decode embedding without vector: %v", err)
+	}
+
+	if emb.Index != 2 || emb.Embedding != nil {
+		t.Errorf("Expected index 2 and no vector, got %+v", emb)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []float64
+		want    float64
+		wantErr bool
+	}{
+		{name: "identical", a: []float64{1, 2, 3}, b: []float64{1, 2, 3}, want: 1},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 1}, want: 0},
+		{name: "opposite", a: []float64{1, 1}, b: []float64{-2, -2}, want: -1},
+		{name: "length mismatch", a: []float64{1, 2}, b: []float64{1}, wantErr: true},
+		{name: "zero vector", a: []float64{0, 0}, b: []float64{1, 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CosineSimilarity(tt.a, tt.b)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CosineSimilarity failed: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected similarity %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEmbeddingSimilarityTo(t *testing.T) {
+	a := Embedding{Embedding: []float64{3, 4}}
+	b := Embedding{Embedding: []float64{6, 8}}
+
+	got, err := a.SimilarityTo(b)
+	if err != nil {
+		t.Fatalf("SimilarityTo failed: %v", err)
+	}
+	if math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected similarity 1, got %v", got)
+	}
+}
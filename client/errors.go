@@ -0,0 +1,156 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrClientClosed возвращается при использовании закрытого клиента
+	ErrClientClosed = errors.New("client is closed")
+	// ErrQuotaExceeded возвращается, когда у пользователя закончились токены или баланс
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrNotSupported возвращается для операций, которые не поддерживает GigaChat API
+	ErrNotSupported = errors.New("operation not supported by GigaChat API")
+	// ErrModelMismatch возвращается при включенном WithValidateResponseModel,
+	// если ответ получен от модели другого семейства, чем запрошенная
+	ErrModelMismatch = errors.New("response model does not match requested model")
+	// ErrInvalidCredentials возвращается, если сервер авторизации отклонил ключ авторизации.
+	// Повторять такой запрос без смены ключа бессмысленно.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrNoChoices возвращается при включенном WithErrorOnEmptyChoices,
+	// если ответ чата не содержит ни одного варианта
+	ErrNoChoices = errors.New("chat response has no choices")
+	// ErrModelNotAllowed возвращается, если модель запроса не входит в список WithAllowedModels
+	ErrModelNotAllowed = errors.New("model is not allowed")
+)
+
+// APIError представляет ошибку, возвращенную GigaChat API
+type APIError struct {
+	// Op описывает неудавшуюся операцию, например "chat"
+	Op         string
+	StatusCode int
+	// Message содержит сообщение об ошибке из тела ответа, если его удалось разобрать
+	Message string
+	// Code содержит код ошибки приложения из тела ответа, если сервер его передал
+	Code int
+	Body string
+	// Usage содержит использование токенов из тела ответа, если сервер его вернул
+	// (например, при превышении допустимой длины запроса)
+	Usage *Usage
+	// RequestBody содержит тело отправленного запроса при включенном WithDebugBodies
+	RequestBody string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestBody != "" {
+		return fmt.Sprintf("failed to %s with status %d: %s (request body: %s)", e.Op, e.StatusCode, e.Body, e.RequestBody)
+	}
+	return fmt.Sprintf("failed to %s with status %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// Is позволяет сопоставлять APIError с ErrQuotaExceeded и ErrInvalidCredentials через errors.Is
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrQuotaExceeded:
+		return e.isQuotaExceeded()
+	case ErrInvalidCredentials:
+		return e.isInvalidCredentials()
+	}
+	return false
+}
+
+// isInvalidCredentials сообщает, указывает ли ошибка авторизации на неверный ключ,
+// а не на временную проблему сервера
+func (e *APIError) isInvalidCredentials() bool {
+	if e.Op != authOp {
+		return false
+	}
+	if e.StatusCode != http.StatusUnauthorized && e.StatusCode != http.StatusForbidden {
+		return false
+	}
+
+	msg := strings.ToLower(e.Message)
+	return strings.Contains(msg, "credentials") ||
+		strings.Contains(msg, "authorization") ||
+		strings.Contains(msg, "scope")
+}
+
+// isQuotaExceeded сообщает, указывает ли ошибка на исчерпание квоты или баланса
+func (e *APIError) isQuotaExceeded() bool {
+	if e.StatusCode == http.StatusPaymentRequired {
+		return true
+	}
+
+	msg := strings.ToLower(e.Message)
+	return strings.Contains(msg, "insufficient balance") || strings.Contains(msg, "quota exceeded")
+}
+
+// authOp - операция APIError для ошибок сервера авторизации
+const authOp = "get access token"
+
+// defaultErrorBodyLimit - максимальный размер тела ответа с ошибкой, сохраняемого в APIError
+const defaultErrorBodyLimit = 64 * 1024
+
+// newAPIError создает APIError из ответа с ошибкой, разбирая тело вида {"status": ..., "message": ...}.
+// Читается не более errorBodyLimit байт тела.
+func (c *Client) newAPIError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, c.errorBodyLimit))
+
+	apiErr := &APIError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+
+	var envelope errorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		envelope.applyTo(apiErr)
+	}
+
+	if c.debugBodies {
+		apiErr.RequestBody = c.requestBody(resp.Request)
+	}
+
+	return apiErr
+}
+
+// requestBody возвращает не более errorBodyLimit байт тела отправленного запроса.
+// Тела загрузок файлов (multipart) не возвращаются: это двоичные данные, а при
+// ограничении скорости повторное чтение тела заняло бы время.
+func (c *Client) requestBody(req *http.Request) string {
+	if req == nil || req.GetBody == nil {
+		return ""
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(body, c.errorBodyLimit))
+	return string(data)
+}
+
+// errorEnvelope представляет тело ответа с ошибкой
+type errorEnvelope struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Usage   *Usage `json:"usage"`
+}
+
+// applyTo переносит сведения из тела ответа в APIError
+func (e errorEnvelope) applyTo(apiErr *APIError) {
+	apiErr.Message = e.Message
+	apiErr.Code = e.Code
+	apiErr.Usage = e.Usage
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"message":"Invalid params"}`))
+	})
+
+	_, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", apiErr.StatusCode)
+	}
+
+	if apiErr.Message != "Invalid params" {
+		t.Errorf("Expected message to be 'Invalid params', got '%s'", apiErr.Message)
+	}
+
+	if apiErr.Usage != nil {
+		t.Errorf("Expected no usage for error without it, got %+v", apiErr.Usage)
+	}
+
+	expected := `failed to chat with status 400: {"status":400,"message":"Invalid params"}`
+	if err.Error() != expected {
+		t.Errorf("Expected error to be '%s', got '%s'", expected, err.Error())
+	}
+
+	if errors.Is(err, ErrQuotaExceeded) {
+		t.Error("Expected bad request not to match ErrQuotaExceeded")
+	}
+}
+
+func TestAPIErrorUsage(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"status":422,"message":"Tokens limit exceeded","usage":{"prompt_tokens":33000,"completion_tokens":0,"total_tokens":33000}}`))
+	})
+
+	_, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.Usage == nil || apiErr.Usage.PromptTokens != 33000 || apiErr.Usage.TotalTokens != 33000 {
+		t.Errorf("Expected usage with 33000 prompt tokens, got %+v", apiErr.Usage)
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"status":402,"message":"Payment Required"}`))
+	})
+
+	_, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestInvalidCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected bool
+	}{
+		{
+			name:     "credentials mismatch",
+			status:   http.StatusUnauthorized,
+			body:     `{"code":6,"message":"credentials doesn't match db data"}`,
+			expected: true,
+		},
+		{
+			name:     "malformed header",
+			status:   http.StatusUnauthorized,
+			body:     `{"code":4,"message":"Can't decode 'Authorization' header"}`,
+			expected: true,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			body:   `{"message":"internal error"}`,
+		},
+		{
+			name:   "unauthorized without details",
+			status: http.StatusUnauthorized,
+			body:   `Unauthorized`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+			srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}
+
+			_, err := srv.client().GetModels(context.Background())
+			if err == nil {
+				t.Fatal("Expected auth error")
+			}
+
+			if got := errors.Is(err, ErrInvalidCredentials); got != tt.expected {
+				t.Errorf("Expected errors.Is(err, ErrInvalidCredentials) to be %v, got %v (%v)", tt.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestErrorBodyLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected int
+	}{
+		{name: "default", expected: defaultErrorBodyLimit},
+		{name: "custom", opts: []Option{WithErrorBodyLimit(1024)}, expected: 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(strings.Repeat("x", 1<<20)))
+			}, tt.opts...)
+
+			_, err := client.GetModels(context.Background())
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected APIError, got %v", err)
+			}
+
+			if len(apiErr.Body) != tt.expected {
+				t.Errorf("Expected body to be truncated to %d bytes, got %d", tt.expected, len(apiErr.Body))
+			}
+		})
+	}
+}
+
+func TestDebugBodies(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"message":"Invalid params"}`))
+	}
+	req := &ChatRequest{Model: "GigaChat:latest", Messages: []ChatMessage{{Role: RoleUser, Content: "secret prompt"}}}
+
+	_, err := newTestClient(t, handler, WithDebugBodies()).Chat(context.Background(), req)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if !strings.Contains(apiErr.RequestBody, "secret prompt") {
+		t.Errorf("Expected request body in APIError, got '%s'", apiErr.RequestBody)
+	}
+
+	if msg := err.Error(); !strings.Contains(msg, "secret prompt") || !strings.Contains(msg, "Invalid params") {
+		t.Errorf("Expected error to contain request and response bodies, got '%s'", msg)
+	}
+
+	_, err = newTestClient(t, handler).Chat(context.Background(), req)
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.RequestBody != "" || strings.Contains(err.Error(), "secret prompt") {
+		t.Errorf("Expected no request body by default, got '%s'", err.Error())
+	}
+}
+
+func TestDebugBodiesSkipsUploads(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"message":"Invalid file"}`))
+	}, WithDebugBodies())
+
+	_, err := client.UploadFileReaderWithOptions(context.Background(),
+		strings.NewReader("file content"), "notes.txt", "text/plain", General,
+		UploadFileOptions{MaxBytesPerSecond: 1 << 20})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.RequestBody != "" {
+		t.Errorf("Expected no request body for multipart upload, got '%s'", apiErr.RequestBody)
+	}
+}
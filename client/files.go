@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/time/rate"
+)
+
+// UploadDir загружает в хранилище все файлы из каталога dir (без подкаталогов).
+// При ошибке возвращаются файлы, загруженные до ее возникновения.
+func (c *Client) UploadDir(ctx context.Context, dir string, purpose Purpose) ([]*File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir: %w", err)
+	}
+
+	var files []*File
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		file, err := c.UploadFile(ctx, filepath.Join(dir, entry.Name()), purpose)
+		if err != nil {
+			return files, fmt.Errorf("failed to upload %s: %w", entry.Name(), err)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// UploadDirAndTrack загружает каталог как UploadDir и возвращает функцию,
+// удаляющую все загруженные файлы. Функция очистки возвращается всегда,
+// в том числе при частичной загрузке.
+func (c *Client) UploadDirAndTrack(
+	ctx context.Context, dir string, purpose Purpose,
+) ([]*File, func(ctx context.Context) error, error) {
+	files, err := c.UploadDir(ctx, dir, purpose)
+
+	ids := make([]string, len(files))
+	for i, file := range files {
+		ids[i] = file.ID
+	}
+
+	cleanup := func(ctx context.Context) error {
+		return c.DeleteFiles(ctx, ids...)
+	}
+
+	return files, cleanup, err
+}
+
+// DeleteFiles удаляет несколько файлов, продолжая работу при ошибках
+func (c *Client) DeleteFiles(ctx context.Context, fileIDs ...string) error {
+	var errs []error
+	for _, id := range fileIDs {
+		if err := c.DeleteFile(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("file %s: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FileUpdate описывает изменения метаданных файла
+type FileUpdate struct {
+	Filename string `json:"filename,omitempty"`
+}
+
+// UpdateFile изменяет метаданные файла. GigaChat API не позволяет изменять
+// загруженные файлы, поэтому метод всегда возвращает ErrNotSupported;
+// чтобы переименовать файл, загрузите его заново.
+func (c *Client) UpdateFile(ctx context.Context, fileID string, update FileUpdate) (*File, error) {
+	return nil, fmt.Errorf("failed to update file %s: %w", fileID, ErrNotSupported)
+}
+
+// FindByName возвращает первый файл с указанным именем
+func (r *FilesResponse) FindByName(name string) (*File, bool) {
+	for i := range r.Data {
+		if r.Data[i].Filename == name {
+			return &r.Data[i], true
+		}
+	}
+	return nil, false
+}
+
+// FilterByPurpose возвращает файлы с указанным назначением
+func (r *FilesResponse) FilterByPurpose(p Purpose) []File {
+	var files []File
+	for _, f := range r.Data {
+		if f.Purpose == string(p) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// FilesListOptions задает параметры постраничного получения списка файлов
+type FilesListOptions struct {
+	// Limit ограничивает число файлов на странице; 0 означает значение сервера по умолчанию
+	Limit int
+	// After задает ID файла, после которого начинается страница
+	After string
+}
+
+// FileIterator последовательно перебирает файлы хранилища, запрашивая страницы по мере необходимости
+type FileIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   FilesListOptions
+	page   []File
+	pos    int
+	done   bool
+	err    error
+}
+
+// FilesIterator возвращает итератор по всем файлам хранилища.
+// Страницы запрашиваются по одной, поэтому весь список не загружается в память.
+func (c *Client) FilesIterator(ctx context.Context, opts FilesListOptions) *FileIterator {
+	return &FileIterator{
+		client: c,
+		ctx:    ctx,
+		opts:   opts,
+	}
+}
+
+// Next возвращает очередной файл. false означает, что файлы закончились или произошла ошибка,
+// которую возвращает Err.
+func (it *FileIterator) Next() (*File, bool) {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return nil, false
+		}
+
+		resp, err := it.client.listFiles(it.ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return nil, false
+		}
+
+		it.page, it.pos = resp.Data, 0
+		if !resp.HasMore || len(resp.Data) == 0 {
+			it.done = true
+		} else {
+			it.opts.After = resp.Data[len(resp.Data)-1].ID
+		}
+	}
+
+	file := &it.page[it.pos]
+	it.pos++
+
+	return file, true
+}
+
+// Err возвращает ошибку, прервавшую перебор
+func (it *FileIterator) Err() error {
+	return it.err
+}
+
+// throttledReader ограничивает скорость чтения из r заданным числом байт в секунду
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// newThrottledReader создает reader, читающий из r не быстрее bytesPerSecond.
+// Допускается всплеск не больше десятой доли секундного объема.
+func newThrottledReader(ctx context.Context, r io.Reader, bytesPerSecond int) *throttledReader {
+	burst := max(bytesPerSecond/10, 1)
+	limiter := rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+	return &throttledReader{ctx: ctx, r: r, limiter: limiter}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > t.limiter.Burst() {
+		p = p[:t.limiter.Burst()]
+	}
+
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,466 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadDirAndTrack(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		uploaded int
+		deleted  []string
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/files":
+			uploaded++
+			json.NewEncoder(w).Encode(File{ID: fmt.Sprintf("file-%d", uploaded)})
+		case r.Method == "DELETE":
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/files/"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+	files, cleanup, err := client.UploadDirAndTrack(ctx, dir, General)
+	if err != nil {
+		t.Fatalf("UploadDirAndTrack failed: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 uploaded files, got %d", len(files))
+	}
+
+	if err := cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	sort.Strings(deleted)
+	if strings.Join(deleted, ",") != "file-1,file-2" {
+		t.Errorf("Expected deleted files to be 'file-1,file-2', got '%s'", strings.Join(deleted, ","))
+	}
+}
+
+func TestUpdateFileNotSupported(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request for unsupported operation")
+	})
+
+	_, err := client.UpdateFile(context.Background(), "file-1", FileUpdate{Filename: "renamed.txt"})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestDownloadFileWithType(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/files/file-1/content" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpeg data"))
+	})
+
+	data, contentType, err := client.DownloadFileWithType(context.Background(), "file-1")
+	if err != nil {
+		t.Fatalf("DownloadFileWithType failed: %v", err)
+	}
+
+	if string(data) != "jpeg data" {
+		t.Errorf("Expected content to be 'jpeg data', got '%s'", string(data))
+	}
+
+	if contentType != "image/jpeg" {
+		t.Errorf("Expected content type to be 'image/jpeg', got '%s'", contentType)
+	}
+}
+
+func TestDownloadFileInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		disposition string
+		want        string
+	}{
+		{name: "filename", disposition: `attachment; filename="report.pdf"`, want: "report.pdf"},
+		{name: "encoded filename", disposition: `attachment; filename*=UTF-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.pdf`, want: "отчет.pdf"},
+		{name: "path", disposition: `attachment; filename="../../etc/passwd"`, want: "passwd"},
+		{name: "no header", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.disposition != "" {
+					w.Header().Set("Content-Disposition", tt.disposition)
+				}
+				w.Header().Set("Content-Type", "application/pdf")
+				w.Write([]byte("pdf data"))
+			})
+
+			data, filename, contentType, err := client.DownloadFileInfo(context.Background(), "file-1")
+			if err != nil {
+				t.Fatalf("DownloadFileInfo failed: %v", err)
+			}
+
+			if string(data) != "pdf data" || contentType != "application/pdf" {
+				t.Errorf("Expected pdf data with application/pdf, got '%s' with '%s'", data, contentType)
+			}
+
+			if filename != tt.want {
+				t.Errorf("Expected filename to be '%s', got '%s'", tt.want, filename)
+			}
+		})
+	}
+}
+
+func TestUploadFileReaderWithOptions(t *testing.T) {
+	var (
+		fields      map[string][]string
+		fileName    string
+		contentType string
+		content     string
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse multipart body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fields = r.MultipartForm.Value
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Failed to get file part: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		data, _ := io.ReadAll(file)
+		fileName = header.Filename
+		contentType = header.Header.Get("Content-Type")
+		content = string(data)
+
+		json.NewEncoder(w).Encode(File{ID: "file-1"})
+	})
+
+	_, err := client.UploadFileReaderWithOptions(context.Background(),
+		strings.NewReader("hello"), "notes.txt", "text/plain", General,
+		UploadFileOptions{ExtraFields: map[string]string{"description": "Meeting notes"}},
+	)
+	if err != nil {
+		t.Fatalf("UploadFileReaderWithOptions failed: %v", err)
+	}
+
+	if got := fields["purpose"]; len(got) != 1 || got[0] != "general" {
+		t.Errorf("Expected purpose to be 'general', got %v", got)
+	}
+
+	if got := fields["description"]; len(got) != 1 || got[0] != "Meeting notes" {
+		t.Errorf("Expected description to be 'Meeting notes', got %v", got)
+	}
+
+	if fileName != "notes.txt" || contentType != "text/plain" || content != "hello" {
+		t.Errorf("Expected file notes.txt (text/plain) with 'hello', got %s (%s) with '%s'", fileName, contentType, content)
+	}
+}
+
+func TestUploadFileContentLength(t *testing.T) {
+	content := strings.Repeat("x", 4096)
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		upload func(*Client) (*File, error)
+	}{
+		{
+			name: "file",
+			upload: func(c *Client) (*File, error) {
+				return c.UploadFile(context.Background(), path, General)
+			},
+		},
+		{
+			name: "throttled reader",
+			upload: func(c *Client) (*File, error) {
+				return c.UploadFileReaderWithOptions(context.Background(),
+					bytes.NewReader([]byte(content)), "notes.txt", "text/plain", General,
+					UploadFileOptions{MaxBytesPerSecond: 1 << 20})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				contentLength    int64
+				transferEncoding []string
+				bodySize         int
+			)
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				contentLength = r.ContentLength
+				transferEncoding = r.TransferEncoding
+				body, _ := io.ReadAll(r.Body)
+				bodySize = len(body)
+				json.NewEncoder(w).Encode(File{ID: "file-1"})
+			})
+
+			if _, err := tt.upload(client); err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+
+			if contentLength <= 0 || contentLength != int64(bodySize) {
+				t.Errorf("Expected Content-Length to match body size %d, got %d", bodySize, contentLength)
+			}
+
+			if len(transferEncoding) != 0 {
+				t.Errorf("Expected no chunked transfer encoding, got %v", transferEncoding)
+			}
+		})
+	}
+}
+
+func TestReaderSize(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "size")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+	file.WriteString("hello world")
+	file.Seek(6, io.SeekStart)
+
+	tests := []struct {
+		name     string
+		reader   io.Reader
+		expected int64
+		ok       bool
+	}{
+		{name: "len", reader: strings.NewReader("hello"), expected: 5, ok: true},
+		{name: "file", reader: file, expected: 5, ok: true},
+		{name: "seeker", reader: io.NewSectionReader(strings.NewReader("hello world"), 0, 11), ok: false},
+		{name: "stream", reader: io.MultiReader(strings.NewReader("hello")), ok: false},
+	}
+
+	for _, tt := range tests {
+		size, ok := readerSize(tt.reader)
+		if ok != tt.ok || size != tt.expected {
+			t.Errorf("%s: expected size %d (%v), got %d (%v)", tt.name, tt.expected, tt.ok, size, ok)
+		}
+	}
+}
+
+func TestFilesIterator(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("after") {
+		case "":
+			json.NewEncoder(w).Encode(FilesResponse{
+				Data:    []File{{ID: "file-1"}, {ID: "file-2"}},
+				HasMore: true,
+			})
+		case "file-2":
+			json.NewEncoder(w).Encode(FilesResponse{
+				Data: []File{{ID: "file-3"}},
+			})
+		default:
+			t.Errorf("Unexpected page request: %s", r.URL.RawQuery)
+		}
+	})
+
+	it := client.FilesIterator(context.Background(), FilesListOptions{Limit: 2})
+
+	var ids []string
+	for file, ok := it.Next(); ok; file, ok = it.Next() {
+		ids = append(ids, file.ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iterator failed: %v", err)
+	}
+
+	if strings.Join(ids, ",") != "file-1,file-2,file-3" {
+		t.Errorf("Expected files from both pages, got %v", ids)
+	}
+
+	if len(queries) != 2 || queries[0] != "limit=2" || queries[1] != "after=file-2&limit=2" {
+		t.Errorf("Expected two page requests, got %v", queries)
+	}
+}
+
+func TestFilesIteratorError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	it := client.FilesIterator(context.Background(), FilesListOptions{})
+	if _, ok := it.Next(); ok {
+		t.Fatal("Expected no files")
+	}
+
+	var apiErr *APIError
+	if !errors.As(it.Err(), &apiErr) {
+		t.Errorf("Expected APIError, got %v", it.Err())
+	}
+}
+
+func TestFilesResponseFindByName(t *testing.T) {
+	files := &FilesResponse{Data: []File{
+		{ID: "file-1", Filename: "a.txt"},
+		{ID: "file-2", Filename: "b.txt"},
+	}}
+
+	file, ok := files.FindByName("b.txt")
+	if !ok || file.ID != "file-2" {
+		t.Errorf("Expected to find file-2, got %+v (%v)", file, ok)
+	}
+
+	if _, ok := files.FindByName("c.txt"); ok {
+		t.Error("Expected missing file not to be found")
+	}
+}
+
+func TestFilesResponseFilterByPurpose(t *testing.T) {
+	files := &FilesResponse{Data: []File{
+		{ID: "file-1", Purpose: "general"},
+		{ID: "file-2", Purpose: "assistants"},
+		{ID: "file-3", Purpose: "general"},
+	}}
+
+	general := files.FilterByPurpose(General)
+	if len(general) != 2 || general[0].ID != "file-1" || general[1].ID != "file-3" {
+		t.Errorf("Expected file-1 and file-3, got %+v", general)
+	}
+
+	if other := files.FilterByPurpose(Purpose("fine-tune")); len(other) != 0 {
+		t.Errorf("Expected no files, got %+v", other)
+	}
+}
+
+func TestUploadFileThrottled(t *testing.T) {
+	var received int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = len(body)
+		json.NewEncoder(w).Encode(File{ID: "file-1"})
+	})
+
+	content := strings.Repeat("x", 10000)
+	start := time.Now()
+	_, err := client.UploadFileReaderWithOptions(context.Background(),
+		strings.NewReader(content), "notes.txt", "text/plain", General,
+		UploadFileOptions{MaxBytesPerSecond: 20000},
+	)
+	if err != nil {
+		t.Fatalf("UploadFileReaderWithOptions failed: %v", err)
+	}
+
+	// Без учета начального всплеска в 2000 байт 10 КБ при 20 КБ/с отправляются не быстрее 0.4 с
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("Expected throttled upload to take at least 300ms, took %s", elapsed)
+	}
+
+	if received <= len(content) {
+		t.Errorf("Expected whole multipart body to be sent, got %d bytes", received)
+	}
+}
+
+func TestUploadFileThrottledCancel(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(File{ID: "file-1"})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.UploadFileReaderWithOptions(ctx,
+		strings.NewReader(strings.Repeat("x", 100000)), "notes.txt", "text/plain", General,
+		UploadFileOptions{MaxBytesPerSecond: 10000},
+	)
+	if err == nil {
+		t.Fatal("Expected error after context cancellation")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to stop the upload promptly, took %s", elapsed)
+	}
+}
+
+func TestUploadFileDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var purpose string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse multipart body: %v", err)
+		}
+		purpose = r.FormValue("purpose")
+		json.NewEncoder(w).Encode(File{ID: "file-1"})
+	}, WithDefaultUploadPurpose(Purpose("assistants")))
+
+	if _, err := client.UploadFileDefault(context.Background(), path); err != nil {
+		t.Fatalf("UploadFileDefault failed: %v", err)
+	}
+
+	if purpose != "assistants" {
+		t.Errorf("Expected purpose to be 'assistants', got '%s'", purpose)
+	}
+}
+
+func TestUploadFileDefaultNotSet(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected upload without a default purpose")
+	})
+
+	if _, err := client.UploadFileDefault(context.Background(), "notes.txt"); err == nil {
+		t.Error("Expected error when default purpose is not set")
+	}
+}
+
+func TestUploadFileReaderEmptyName(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected upload with an empty file name")
+	})
+
+	for _, name := range []string{"", "  "} {
+		_, err := client.UploadFileReader(context.Background(), strings.NewReader("data"), name, "text/plain", General)
+		if err == nil || !strings.Contains(err.Error(), "invalid file name") {
+			t.Errorf("Expected invalid file name error for %q, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// functionModels содержит семейства моделей, поддерживающих вызов функций
+var functionModels = map[string]bool{
+	"gigachat":       true,
+	"gigachat-pro":   true,
+	"gigachat-max":   true,
+	"gigachat-2":     true,
+	"gigachat-2-pro": true,
+	"gigachat-2-max": true,
+}
+
+// SupportsFunctions сообщает, поддерживает ли модель вызов функций
+func (m Model) SupportsFunctions() bool {
+	name := m.ID
+	if name == "" {
+		name = m.Name
+	}
+	return SupportsFunctions(name)
+}
+
+// SupportsFunctions сообщает, поддерживает ли модель с указанным именем вызов функций.
+// Версия модели ("GigaChat:latest") и суффикс "-preview" при проверке не учитываются.
+func SupportsFunctions(model string) bool {
+	family := strings.ToLower(modelFamily(model))
+	family = strings.TrimSuffix(family, "-preview")
+	return functionModels[family]
+}
+
+// modelsCache хранит последний успешно полученный список моделей
+type modelsCache struct {
+	mu           sync.Mutex
+	ttl          time.Duration
+	staleOnError bool
+	models       *ModelsResponse
+	fetchedAt    time.Time
+}
+
+// getModelsCached возвращает список моделей из кэша, обновляя его по истечении ttl.
+// Если обновить список не удалось и включен WithStaleModelsOnError, возвращается
+// последний полученный список с признаком Stale.
+func (c *Client) getModelsCached(ctx context.Context) (*ModelsResponse, error) {
+	cache := c.modelsCache
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.models != nil && time.Since(cache.fetchedAt) < cache.ttl {
+		return cache.models.clone(), nil
+	}
+
+	models, err := c.getModels(ctx)
+	if err != nil {
+		if cache.staleOnError && cache.models != nil {
+			stale := cache.models.clone()
+			stale.Stale = true
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	cache.models = models.clone()
+	cache.fetchedAt = time.Now()
+
+	return models, nil
+}
+
+// clone возвращает копию списка моделей
+func (r *ModelsResponse) clone() *ModelsResponse {
+	clone := *r
+	clone.Data = slices.Clone(r.Data)
+	return &clone
+}
+
+// checkModelAllowed проверяет модель по списку WithAllowedModels. Модель разрешена,
+// если в списке есть ее полное имя или имя без версии: "GigaChat" разрешает "GigaChat:latest".
+func (c *Client) checkModelAllowed(model string) error {
+	if c.allowedModels == nil {
+		return nil
+	}
+
+	for _, allowed := range c.allowedModels {
+		if strings.EqualFold(allowed, model) || strings.EqualFold(allowed, modelFamily(model)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q is not in the allowed models list", ErrModelNotAllowed, model)
+}
+
+// errNoModelVersions возвращается ResolveModel, если в списке моделей нет конкретных версий семейства
+var errNoModelVersions = errors.New("no versions found")
+
+// ResolveModel заменяет версию "latest" в имени модели ("GigaChat:latest") на самую новую
+// конкретную версию из списка GetModels. При включенном WithModelPinning результат запоминается,
+// и дальнейшие вызовы возвращают ту же версию. Модели без версии "latest" возвращаются без изменений.
+func (c *Client) ResolveModel(ctx context.Context, model string) (string, error) {
+	family, version, _ := strings.Cut(model, ":")
+	if version != "latest" {
+		return model, nil
+	}
+
+	if pinned, ok := c.PinnedModel(model); ok {
+		return pinned, nil
+	}
+
+	models, err := c.GetModels(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve model %s: %w", model, err)
+	}
+
+	var resolved, resolvedVersion string
+	for _, m := range models.Data {
+		f, v, ok := strings.Cut(m.ID, ":")
+		if !ok || v == "latest" || !strings.EqualFold(f, family) {
+			continue
+		}
+		if resolved == "" || compareVersions(v, resolvedVersion) > 0 {
+			resolved, resolvedVersion = m.ID, v
+		}
+	}
+
+	if resolved == "" {
+		return "", fmt.Errorf("failed to resolve model %s: %w", model, errNoModelVersions)
+	}
+
+	if c.pinnedModels != nil {
+		c.mu.Lock()
+		if pinned, ok := c.pinnedModels[model]; ok {
+			resolved = pinned
+		} else {
+			c.pinnedModels[model] = resolved
+		}
+		c.mu.Unlock()
+	}
+
+	return resolved, nil
+}
+
+// PinnedModel возвращает версию модели, закрепленную за model при включенном WithModelPinning
+func (c *Client) PinnedModel(model string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pinned, ok := c.pinnedModels[model]
+	return pinned, ok
+}
+
+// compareVersions сравнивает версии вида "1.0.26.20" по числовым компонентам
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
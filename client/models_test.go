@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupportsFunctions(t *testing.T) {
+	tests := []struct {
+		model    Model
+		expected bool
+	}{
+		{model: Model{ID: "GigaChat"}, expected: true},
+		{model: Model{ID: "GigaChat:latest"}, expected: true},
+		{model: Model{ID: "GigaChat-Pro"}, expected: true},
+		{model: Model{ID: "GigaChat-2-Max"}, expected: true},
+		{model: Model{ID: "GigaChat-2-Pro-preview"}, expected: true},
+		{model: Model{Name: "GigaChat-Max"}, expected: true},
+		{model: Model{ID: "Embeddings"}, expected: false},
+		{model: Model{ID: "EmbeddingsGigaR"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.model.SupportsFunctions(); got != tt.expected {
+			t.Errorf("Expected SupportsFunctions for %+v to be %v, got %v", tt.model, tt.expected, got)
+		}
+	}
+}
+
+func TestValidateFunctionSupport(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request for a model without function support")
+	}, WithValidateFunctionSupport())
+
+	_, err := client.Chat(context.Background(), &ChatRequest{
+		Model:     "Embeddings",
+		Messages:  []ChatMessage{{Role: RoleUser, Content: "Hello"}},
+		Functions: []Function{{Name: "get_weather"}},
+	})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestModelsCache(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(ModelsResponse{Data: []Model{{ID: "GigaChat"}}})
+	}, WithModelsCache(time.Hour))
+
+	for range 2 {
+		if _, err := client.GetModels(context.Background()); err != nil {
+			t.Fatalf("GetModels failed: %v", err)
+		}
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected models to be fetched once, got %d requests", got)
+	}
+}
+
+func TestStaleModelsOnError(t *testing.T) {
+	var fail atomic.Bool
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(ModelsResponse{Data: []Model{{ID: "GigaChat"}}})
+	}, WithModelsCache(time.Hour), WithStaleModelsOnError())
+
+	models, err := client.GetModels(context.Background())
+	if err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+	if models.Stale {
+		t.Error("Expected fresh models not to be stale")
+	}
+
+	// Имитируем истечение срока кэша и сбой обновления
+	client.modelsCache.fetchedAt = time.Now().Add(-2 * time.Hour)
+	fail.Store(true)
+
+	models, err = client.GetModels(context.Background())
+	if err != nil {
+		t.Fatalf("Expected stale models instead of error, got %v", err)
+	}
+
+	if !models.Stale {
+		t.Error("Expected models to be marked stale")
+	}
+
+	if len(models.Data) != 1 || models.Data[0].ID != "GigaChat" {
+		t.Errorf("Expected cached models, got %+v", models.Data)
+	}
+}
+
+func TestModelsRefreshErrorWithoutStale(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}, WithModelsCache(time.Hour))
+
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Error("Expected error without WithStaleModelsOnError")
+	}
+}
+
+func TestModelPinning(t *testing.T) {
+	var (
+		chatModels     []string
+		modelsRequests atomic.Int32
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models":
+			modelsRequests.Add(1)
+			json.NewEncoder(w).Encode(ModelsResponse{Data: []Model{
+				{ID: "GigaChat"},
+				{ID: "GigaChat:1.0.26.9"},
+				{ID: "GigaChat:1.0.26.20"},
+				{ID: "GigaChat-Pro:2.0.28.2"},
+			}})
+		case "/chat/completions":
+			var req ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			chatModels = append(chatModels, req.Model)
+			json.NewEncoder(w).Encode(ChatResponse{})
+		}
+	}, WithModelPinning())
+
+	for range 2 {
+		if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+
+	if len(chatModels) != 2 || chatModels[0] != "GigaChat:1.0.26.20" || chatModels[1] != "GigaChat:1.0.26.20" {
+		t.Errorf("Expected both requests to use pinned 'GigaChat:1.0.26.20', got %v", chatModels)
+	}
+
+	if got := modelsRequests.Load(); got != 1 {
+		t.Errorf("Expected models to be listed once, got %d requests", got)
+	}
+
+	if pinned, ok := client.PinnedModel("GigaChat:latest"); !ok || pinned != "GigaChat:1.0.26.20" {
+		t.Errorf("Expected pinned model 'GigaChat:1.0.26.20', got '%s' (%v)", pinned, ok)
+	}
+}
+
+func TestModelPinningUnversionedModels(t *testing.T) {
+	var chatModel string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models":
+			json.NewEncoder(w).Encode(ModelsResponse{Data: []Model{{ID: "GigaChat"}, {ID: "GigaChat-Pro"}}})
+		case "/chat/completions":
+			var req ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			chatModel = req.Model
+			json.NewEncoder(w).Encode(ChatResponse{})
+		}
+	}, WithModelPinning())
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if chatModel != "GigaChat:latest" {
+		t.Errorf("Expected requested model to be sent unchanged, got '%s'", chatModel)
+	}
+
+	if pinned, ok := client.PinnedModel("GigaChat:latest"); ok {
+		t.Errorf("Expected nothing to be pinned without versions, got '%s'", pinned)
+	}
+
+	if _, err := client.ResolveModel(context.Background(), "GigaChat:latest"); err == nil {
+		t.Error("Expected ResolveModel to report missing versions")
+	}
+}
+
+func TestResolveModelWithoutLatest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request for a concrete model")
+	})
+
+	model, err := client.ResolveModel(context.Background(), "GigaChat-Pro")
+	if err != nil {
+		t.Fatalf("ResolveModel failed: %v", err)
+	}
+
+	if model != "GigaChat-Pro" {
+		t.Errorf("Expected model to stay 'GigaChat-Pro', got '%s'", model)
+	}
+}
+
+func TestAllowedModels(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/embeddings" {
+			w.Write([]byte(`{"object":"list","data":[{"embedding":[1],"index":0}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"OK"}}]}`))
+	}, WithAllowedModels("GigaChat", "Embeddings"))
+
+	ctx := context.Background()
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat-Max"}); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("Expected ErrModelNotAllowed for chat, got %v", err)
+	}
+	if _, err := client.CreateEmbedding(ctx, "EmbeddingsGigaR", "hello"); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("Expected ErrModelNotAllowed for embeddings, got %v", err)
+	}
+
+	if got := requests.Load(); got != 0 {
+		t.Fatalf("Expected disallowed models to be rejected before any request, got %d requests", got)
+	}
+
+	if _, err := client.Chat(ctx, &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Errorf("Expected allowed chat model to pass, got %v", err)
+	}
+	if _, err := client.CreateEmbedding(ctx, "Embeddings", "hello"); err != nil {
+		t.Errorf("Expected allowed embeddings model to pass, got %v", err)
+	}
+}
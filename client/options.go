@@ -1,23 +1,511 @@
 package client
 
-import "net/http"
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
 
 type Option func(*Client)
 
+// invalidOption запоминает первую ошибку конфигурации, которую затем возвращают все запросы клиента
+func (c *Client) invalidOption(format string, args ...any) {
+	if c.configErr == nil {
+		c.configErr = fmt.Errorf("invalid client option: "+format, args...)
+	}
+}
+
 func WithHTTPClient(cl *http.Client) Option {
 	return func(c *Client) {
 		c.httpClient = cl
 	}
 }
 
+// WithBaseURL задает адрес API. Завершающие слэши отбрасываются, чтобы пути методов не дублировали их.
 func WithBaseURL(baseURL string) Option {
 	return func(c *Client) {
-		c.baseURL = baseURL
+		c.baseURL = strings.TrimRight(baseURL, "/")
 	}
 }
 
+// WithAuthURL задает адрес сервера авторизации. Завершающие слэши отбрасываются.
 func WithAuthURL(authURL string) Option {
 	return func(c *Client) {
-		c.authURL = authURL
+		c.authURL = strings.TrimRight(authURL, "/")
+	}
+}
+
+// Region определяет набор адресов API и сервера авторизации GigaChat
+type Region string
+
+const (
+	// RegionRU - основной стенд GigaChat, используется по умолчанию
+	RegionRU Region = "ru"
+	// RegionPreview - стенд с preview-версиями моделей
+	RegionPreview Region = "preview"
+)
+
+// regionEndpoints содержит адреса API и сервера авторизации для каждого Region
+var regionEndpoints = map[Region]struct{ baseURL, authURL string }{
+	RegionRU: {
+		baseURL: "https://gigachat.devices.sberbank.ru/api/v1",
+		authURL: "https://ngw.devices.sberbank.ru:9443/api/v2/oauth",
+	},
+	RegionPreview: {
+		baseURL: "https://gigachat-preview.devices.sberbank.ru/api/v1",
+		authURL: "https://ngw.devices.sberbank.ru:9443/api/v2/oauth",
+	},
+}
+
+// WithRegion задает адреса API и сервера авторизации для стенда r.
+// Опции WithBaseURL и WithAuthURL, указанные после нее, переопределяют эти адреса.
+func WithRegion(r Region) Option {
+	return func(c *Client) {
+		endpoints, ok := regionEndpoints[r]
+		if !ok {
+			c.invalidOption("unknown region %q", r)
+			return
+		}
+		c.baseURL = endpoints.baseURL
+		c.authURL = endpoints.authURL
+	}
+}
+
+// WithStreamBufferSize задает максимальный размер одного события потока
+func WithStreamBufferSize(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.invalidOption("stream buffer size must be positive, got %d", n)
+			return
+		}
+		c.streamBufferSize = n
+	}
+}
+
+// WithLogger включает логирование запросов на уровне Debug
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithForceTokenRefresh заставляет клиент получать новый токен перед каждым запросом.
+// Предназначена для отладки проблем авторизации.
+func WithForceTokenRefresh() Option {
+	return func(c *Client) {
+		c.forceTokenRefresh = true
+	}
+}
+
+// WithAuthHeaderScheme задает схему заголовка Authorization для запросов к API (по умолчанию Bearer)
+func WithAuthHeaderScheme(scheme string) Option {
+	return func(c *Client) {
+		if scheme == "" {
+			c.invalidOption("auth header scheme must not be empty")
+			return
+		}
+		c.authScheme = scheme
+	}
+}
+
+// WithCompression запрашивает ответы, сжатые gzip, и распаковывает их
+func WithCompression() Option {
+	return func(c *Client) {
+		c.compression = true
+	}
+}
+
+// WithOnTokenRefresh задает функцию, вызываемую после каждого успешного получения токена
+func WithOnTokenRefresh(fn func(token string, expiry time.Time)) Option {
+	return func(c *Client) {
+		c.onTokenRefresh = fn
+	}
+}
+
+// WithRequestTimeout ограничивает время выполнения каждого запроса, если у контекста
+// вызова нет собственного дедлайна. На потоковые запросы не распространяется.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.invalidOption("request timeout must be positive, got %s", d)
+			return
+		}
+		c.requestTimeout = d
+	}
+}
+
+// WithRequestEditor добавляет функцию, изменяющую запрос к API непосредственно перед отправкой
+// (например, для подписи). Ошибка функции прерывает запрос. Функции вызываются в порядке добавления.
+func WithRequestEditor(fn func(*http.Request) error) Option {
+	return func(c *Client) {
+		c.requestEditors = append(c.requestEditors, fn)
+	}
+}
+
+// WithStreamHeartbeatTimeout прерывает поток с ошибкой ErrStreamStalled,
+// если от сервера не приходит данных дольше d
+func WithStreamHeartbeatTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.invalidOption("stream heartbeat timeout must be positive, got %s", d)
+			return
+		}
+		c.streamHeartbeatTimeout = d
+	}
+}
+
+// WithRequestIDGenerator задает функцию генерации идентификаторов запросов (RqUID).
+// По умолчанию используется случайный UUID.
+func WithRequestIDGenerator(fn func() string) Option {
+	return func(c *Client) {
+		if fn == nil {
+			c.invalidOption("request ID generator must not be nil")
+			return
+		}
+		c.requestIDGenerator = fn
+	}
+}
+
+// WithEmbeddingCache включает LRU-кэш эмбеддингов на size записей.
+// Эмбеддинги текстов, найденных в кэше, не запрашиваются повторно.
+func WithEmbeddingCache(size int) Option {
+	return func(c *Client) {
+		if size <= 0 {
+			c.invalidOption("embedding cache size must be positive, got %d", size)
+			return
+		}
+		c.embeddingCache = newEmbeddingCache(size)
+	}
+}
+
+// WithMaxRetries задает число повторов запроса при сетевых ошибках и ответах 429 и 5xx.
+// По умолчанию запросы не повторяются.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		if n < 0 {
+			c.invalidOption("max retries must not be negative, got %d", n)
+			return
+		}
+		c.maxRetries = n
+	}
+}
+
+// WithRetryNonIdempotent разрешает повторять неидемпотентные запросы (POST): чат, эмбеддинги, загрузку файлов.
+// По умолчанию повторяются только GET и DELETE, так как повтор POST может, например, дважды сгенерировать ответ.
+func WithRetryNonIdempotent() Option {
+	return func(c *Client) {
+		c.retryNonIdempotent = true
+	}
+}
+
+// WithRetryBackoff задает начальную паузу между повторами, удваивающуюся с каждой попыткой
+// до 30 секунд
+func WithRetryBackoff(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.invalidOption("retry backoff must be positive, got %s", d)
+			return
+		}
+		c.retryBackoff = d
+	}
+}
+
+// WithMaxRetryElapsed ограничивает общее время попыток запроса: повтор не выполняется,
+// если вместе с паузой перед ним время с первой попытки превысило бы d.
+// В этом случае возвращается результат последней попытки. Действует вместе с WithMaxRetries.
+func WithMaxRetryElapsed(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.invalidOption("max retry elapsed time must be positive, got %s", d)
+			return
+		}
+		c.maxRetryElapsed = d
+	}
+}
+
+// WithOnRetry задает функцию, вызываемую перед каждым повтором запроса с номером повтора
+// (начиная с 1), причиной повтора и паузой до него
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) Option {
+	return func(c *Client) {
+		c.onRetry = fn
+	}
+}
+
+// WithRetryErrorCodes задает коды ошибок приложения GigaChat (поле code тела ответа),
+// при которых запрос повторяется независимо от HTTP статуса, например при временной
+// перегрузке модели. Такие ответы повторяются и для POST (чат, эмбеддинги) без
+// WithRetryNonIdempotent. Число повторов задается WithMaxRetries.
+func WithRetryErrorCodes(codes ...int) Option {
+	return func(c *Client) {
+		if len(codes) == 0 {
+			c.invalidOption("retry error codes must not be empty")
+			return
+		}
+		c.retryErrorCodes = append(c.retryErrorCodes, codes...)
+	}
+}
+
+// WithChatDefaults задает параметры генерации для запросов на чат, в которых они не указаны
+func WithChatDefaults(defaults ChatDefaults) Option {
+	return func(c *Client) {
+		c.chatDefaults = defaults
+	}
+}
+
+// WithChatPath переопределяет относительный путь метода чата (по умолчанию /chat/completions)
+func WithChatPath(path string) Option {
+	return func(c *Client) {
+		if c.validPath("chat", path) {
+			c.chatPath = path
+		}
+	}
+}
+
+// WithEmbeddingsPath переопределяет относительный путь метода эмбеддингов (по умолчанию /embeddings)
+func WithEmbeddingsPath(path string) Option {
+	return func(c *Client) {
+		if c.validPath("embeddings", path) {
+			c.embeddingsPath = path
+		}
+	}
+}
+
+// WithModelsPath переопределяет относительный путь списка моделей (по умолчанию /models)
+func WithModelsPath(path string) Option {
+	return func(c *Client) {
+		if c.validPath("models", path) {
+			c.modelsPath = path
+		}
+	}
+}
+
+// WithFilesPath переопределяет относительный путь методов работы с файлами (по умолчанию /files)
+func WithFilesPath(path string) Option {
+	return func(c *Client) {
+		if c.validPath("files", path) {
+			c.filesPath = path
+		}
+	}
+}
+
+// validPath проверяет, что путь метода не пустой и начинается с "/"
+func (c *Client) validPath(name, path string) bool {
+	if !strings.HasPrefix(path, "/") {
+		c.invalidOption("%s path must start with \"/\", got %q", name, path)
+		return false
+	}
+	return true
+}
+
+// WithAllowedModels разрешает запросы на чат и эмбеддинги только к перечисленным моделям.
+// Запрос к другой модели отклоняется с ErrModelNotAllowed до отправки. Имя без версии
+// ("GigaChat") разрешает все версии модели.
+func WithAllowedModels(names ...string) Option {
+	return func(c *Client) {
+		if len(names) == 0 {
+			c.invalidOption("allowed models must not be empty")
+			return
+		}
+		c.allowedModels = append(c.allowedModels, names...)
+	}
+}
+
+// WithErrorOnEmptyChoices заставляет Chat возвращать ErrNoChoices вместо ответа без вариантов
+func WithErrorOnEmptyChoices() Option {
+	return func(c *Client) {
+		c.errorOnEmptyChoices = true
+	}
+}
+
+// WithValidateResponseModel включает проверку того, что ответ чата получен от модели запрошенного семейства.
+// При несовпадении Chat возвращает ErrModelMismatch, что помогает обнаружить ошибки маршрутизации в прокси.
+func WithValidateResponseModel() Option {
+	return func(c *Client) {
+		c.validateResponseModel = true
+	}
+}
+
+// WithBackgroundRefresh включает фоновое обновление токена с указанным интервалом,
+// чтобы запросы не ждали получения токена. Обновление останавливается при вызове Close.
+func WithBackgroundRefresh(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval <= 0 {
+			c.invalidOption("background refresh interval must be positive, got %s", interval)
+			return
+		}
+		c.backgroundRefresh = interval
+	}
+}
+
+// WithValidateFunctionSupport включает проверку того, что модель запроса с функциями поддерживает их вызов.
+// Для неподходящей модели Chat возвращает ErrNotSupported, не выполняя запрос.
+func WithValidateFunctionSupport() Option {
+	return func(c *Client) {
+		c.validateFunctionSupport = true
+	}
+}
+
+// WithResponseHeaderCallback задает функцию, которая получает заголовки каждого ответа API
+// (например, лимиты запросов или X-Request-ID) вместе с путем запроса
+func WithResponseHeaderCallback(fn func(path string, h http.Header)) Option {
+	return func(c *Client) {
+		c.onResponseHeader = fn
+	}
+}
+
+// WithModelsCache кэширует список моделей, возвращаемый GetModels, на время ttl
+func WithModelsCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl <= 0 {
+			c.invalidOption("models cache TTL must be positive, got %s", ttl)
+			return
+		}
+		if c.modelsCache == nil {
+			c.modelsCache = &modelsCache{}
+		}
+		c.modelsCache.ttl = ttl
+	}
+}
+
+// WithStaleModelsOnError возвращает из GetModels последний успешно полученный список моделей
+// с признаком Stale, если обновить его не удалось. Без WithModelsCache список запрашивается
+// при каждом вызове, а кэш используется только при ошибке.
+func WithStaleModelsOnError() Option {
+	return func(c *Client) {
+		if c.modelsCache == nil {
+			c.modelsCache = &modelsCache{}
+		}
+		c.modelsCache.staleOnError = true
+	}
+}
+
+// WithMaxMessages ограничивает число сообщений в запросе на чат.
+// Запрос с большим числом сообщений отклоняется до отправки на сервер.
+func WithMaxMessages(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.invalidOption("max messages must be positive, got %d", n)
+			return
+		}
+		c.maxMessages = n
+	}
+}
+
+// WithChatRequestHook добавляет функцию, изменяющую запрос на чат перед отправкой
+// (например, для добавления системного промпта). Функция получает копию запроса вызывающего кода
+// и вызывается до применения WithChatDefaults. Функции вызываются в порядке добавления.
+func WithChatRequestHook(fn func(*ChatRequest)) Option {
+	return func(c *Client) {
+		c.chatRequestHooks = append(c.chatRequestHooks, fn)
+	}
+}
+
+// WithModelPinning включает закрепление версий моделей: "GigaChat:latest" в запросах на чат
+// при первом использовании заменяется на конкретную версию из GetModels, и дальнейшие запросы
+// используют ту же версию. Закрепленную версию возвращает PinnedModel. Если GetModels
+// не перечисляет версии семейства, запросы отправляются с исходным именем модели.
+func WithModelPinning() Option {
+	return func(c *Client) {
+		c.pinnedModels = make(map[string]string)
+	}
+}
+
+// WithErrorBodyLimit ограничивает размер тела ответа с ошибкой, сохраняемого в APIError.Body
+// (по умолчанию 64 КБ)
+func WithErrorBodyLimit(n int64) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.invalidOption("error body limit must be positive, got %d", n)
+			return
+		}
+		c.errorBodyLimit = n
+	}
+}
+
+// WithDebugBodies добавляет в APIError тело отправленного запроса (не более WithErrorBodyLimit байт),
+// чтобы при отладке было видно, что отправлено и что получено в ответ.
+// Тела загрузок файлов не добавляются. Запросы и ответы могут содержать персональные данные,
+// поэтому в production опцию включать не стоит.
+func WithDebugBodies() Option {
+	return func(c *Client) {
+		c.debugBodies = true
+	}
+}
+
+// WithRateLimiter ограничивает частоту запросов к API: перед каждой попыткой отправки
+// клиент ожидает разрешения r. Ожидание прерывается при отмене контекста запроса.
+func WithRateLimiter(r *rate.Limiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = r
+	}
+}
+
+// WithDefaultUploadPurpose задает назначение файлов, загружаемых через UploadFileDefault
+func WithDefaultUploadPurpose(p Purpose) Option {
+	return func(c *Client) {
+		if p == "" {
+			c.invalidOption("default upload purpose must not be empty")
+			return
+		}
+		c.defaultUploadPurpose = p
+	}
+}
+
+// WithAuthStrategy задает способ получения токена доступа вместо ClientCredentialsStrategy
+func WithAuthStrategy(s AuthStrategy) Option {
+	return func(c *Client) {
+		if s == nil {
+			c.invalidOption("auth strategy must not be nil")
+			return
+		}
+		c.authStrategy = s
+	}
+}
+
+// WithInitialTokenFile загружает токен доступа из JSON файла {"access_token", "expires_at"}
+// при создании клиента. Пока токен действителен, клиент не обращается к серверу авторизации;
+// после истечения токен обновляется обычным образом по ключу авторизации.
+func WithInitialTokenFile(path string) Option {
+	return func(c *Client) {
+		if path == "" {
+			c.invalidOption("token file path must not be empty")
+			return
+		}
+
+		tokenResp, err := readTokenFile(path)
+		if err != nil {
+			c.invalidOption("%v", err)
+			return
+		}
+
+		c.accessToken = tokenResp.AccessToken
+		c.tokenExpiry = time.Unix(tokenResp.ExpiresAt, 0)
+		if claims, ok := parseTokenClaims(tokenResp.AccessToken); ok {
+			c.tokenScope = claims.Scope
+		}
+	}
+}
+
+// WithBatchConcurrency задает число пакетов CreateEmbeddingsBatched, отправляемых
+// одновременно (по умолчанию пакеты отправляются последовательно)
+func WithBatchConcurrency(n int) Option {
+	return func(c *Client) {
+		if n < 1 {
+			c.invalidOption("batch concurrency must be at least 1, got %d", n)
+			return
+		}
+		c.batchConcurrency = n
+	}
+}
+
+// WithMethodOverride отправляет запросы GET и DELETE методом POST с исходным методом
+// в заголовке X-HTTP-Method-Override. Нужно для шлюзов, пропускающих только POST.
+func WithMethodOverride() Option {
+	return func(c *Client) {
+		c.methodOverride = true
 	}
 }
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// newTicker создает тикер и возвращает его канал и функцию остановки
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// startBackgroundRefresh запускает фоновое обновление токена с интервалом backgroundRefresh.
+// Обновление останавливается при вызове Close.
+func (c *Client) startBackgroundRefresh() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopRefresh = cancel
+	c.refreshDone = make(chan struct{})
+
+	ticks, stop := c.newTicker(c.backgroundRefresh)
+
+	go func() {
+		defer close(c.refreshDone)
+		defer stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				if err := c.GetAccessToken(ctx, GIGACHAT_API_PERS); err != nil && ctx.Err() == nil && c.logger != nil {
+					c.logger.Warn("gigachat background token refresh failed", slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+}
+
+// stopBackgroundRefresh останавливает фоновое обновление токена и дожидается его завершения
+func (c *Client) stopBackgroundRefresh() {
+	if c.stopRefresh == nil {
+		return
+	}
+
+	c.stopRefresh()
+	<-c.refreshDone
+}
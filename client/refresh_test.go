@@ -0,0 +1,61 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// withFakeTicker подменяет тикер фонового обновления каналом ticks
+func withFakeTicker(ticks chan time.Time) Option {
+	return func(c *Client) {
+		c.newTicker = func(time.Duration) (<-chan time.Time, func()) {
+			return ticks, func() {}
+		}
+	}
+}
+
+func TestBackgroundRefresh(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	refreshed := make(chan string, 1)
+	ticks := make(chan time.Time)
+	client := srv.client(
+		WithBackgroundRefresh(time.Millisecond),
+		withFakeTicker(ticks),
+		WithOnTokenRefresh(func(token string, expiry time.Time) {
+			refreshed <- token
+		}),
+	)
+
+	ticks <- time.Now()
+
+	select {
+	case token := <-refreshed:
+		if token != "test_token_1" {
+			t.Errorf("Expected proactively fetched token 'test_token_1', got '%s'", token)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a proactive token refresh")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	select {
+	case ticks <- time.Now():
+		t.Error("Expected background refresh to stop after Close")
+	default:
+	}
+}
+
+func TestBackgroundRefreshDisabledByDefault(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	client := srv.client()
+	defer client.Close()
+
+	if client.stopRefresh != nil {
+		t.Error("Expected background refresh to be off by default")
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+	// maxRetryBackoff ограничивает рост экспоненциальной паузы между повторами
+	maxRetryBackoff = 30 * time.Second
+)
+
+// isIdempotent сообщает, можно ли безопасно повторить запрос с методом method.
+// Повтор POST может привести, например, к повторной генерации ответа чата.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// canRetry сообщает, можно ли повторить запрос после полученного результата.
+// Неидемпотентный запрос повторяется только при коде ошибки из WithRetryErrorCodes:
+// такой ответ явно означает, что запрос не был обработан.
+func (c *Client) canRetry(
+	ctx context.Context, req *http.Request, resp *http.Response, err error, idempotent bool,
+) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if !idempotent {
+		return err == nil && c.hasRetryErrorCode(resp)
+	}
+
+	if err != nil {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return c.hasRetryErrorCode(resp)
+}
+
+// hasRetryErrorCode сообщает, содержит ли ответ с ошибкой код приложения из WithRetryErrorCodes.
+// Прочитанное тело ответа подменяется копией, чтобы его можно было разобрать повторно.
+func (c *Client) hasRetryErrorCode(resp *http.Response) bool {
+	if len(c.retryErrorCodes) == 0 || resp.StatusCode < http.StatusBadRequest {
+		return false
+	}
+
+	if c.compression {
+		if err := decompressResponse(resp); err != nil {
+			return false
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.errorBodyLimit))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var envelope struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return false
+	}
+
+	return envelope.Code != 0 && slices.Contains(c.retryErrorCodes, envelope.Code)
+}
+
+// retryDelay возвращает паузу перед повтором: экспоненциальную от retryBackoff, но не больше
+// maxRetryBackoff (или самой retryBackoff, если она больше), либо заданную сервером
+// в заголовке Retry-After, если она больше
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	limit := max(c.retryBackoff, maxRetryBackoff)
+	delay := c.retryBackoff
+	for range attempt {
+		if delay >= limit/2 {
+			delay = limit
+			break
+		}
+		delay *= 2
+	}
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			delay = max(delay, time.Duration(seconds)*time.Second)
+		}
+	}
+
+	return delay
+}
+
+// retryReason возвращает ошибку, из-за которой запрос будет повторен
+func retryReason(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// sleepContext ждет d или отмены ctx
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rewindBody восстанавливает тело запроса перед повторной отправкой
+func rewindBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	req.Body = body
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unavailableHandler всегда отвечает 503 и считает запросы
+func unavailableHandler(requests *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, unavailableHandler(&requests),
+		WithMaxRetries(3),
+		WithRetryBackoff(time.Millisecond),
+	)
+
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+
+	if requests.Load() != 4 {
+		t.Errorf("Expected 4 attempts, got %d", requests.Load())
+	}
+}
+
+func TestRetryCountFromContext(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, unavailableHandler(&requests),
+		WithMaxRetries(3),
+		WithRetryBackoff(time.Millisecond),
+	)
+
+	ctx := WithRetryCount(context.Background(), 0)
+	if _, err := client.GetModels(ctx); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected retries to be disabled by context, got %d attempts", requests.Load())
+	}
+
+	requests.Store(0)
+	ctx = WithRetryCount(context.Background(), -1)
+	if _, err := client.GetModels(ctx); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 4 {
+		t.Errorf("Expected negative retry count to keep client default, got %d attempts", requests.Load())
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[]}`))
+	}, WithMaxRetries(1), WithRetryBackoff(time.Millisecond), WithRetryNonIdempotent())
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", requests.Load())
+	}
+}
+
+func TestRetryIdempotentOnly(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, unavailableHandler(&requests),
+		WithMaxRetries(2),
+		WithRetryBackoff(time.Millisecond),
+	)
+
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("Expected GET to be retried, got %d attempts", requests.Load())
+	}
+
+	requests.Store(0)
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected POST not to be retried by default, got %d attempts", requests.Load())
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, unavailableHandler(&requests),
+		WithMaxRetries(2),
+		WithRetryBackoff(time.Millisecond),
+		WithRetryNonIdempotent(),
+	)
+
+	if _, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); err == nil {
+		t.Fatal("Expected error for unavailable server")
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("Expected POST to be retried with WithRetryNonIdempotent, got %d attempts", requests.Load())
+	}
+}
+
+// errorCodeHandler отвечает 400 с кодом ошибки code на первые failures запросов
+func errorCodeHandler(requests *atomic.Int32, code, failures int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"status":400,"code":%d,"message":"Model is overloaded"}`, code)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}
+}
+
+func TestRetryErrorCodes(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, errorCodeHandler(&requests, 7, 2),
+		WithMaxRetries(2),
+		WithRetryBackoff(time.Millisecond),
+		WithRetryErrorCodes(7),
+	)
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("Expected request with retryable code to be retried, got %d attempts", requests.Load())
+	}
+}
+
+func TestRetryErrorCodesChat(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":400,"code":7,"message":"Model is overloaded"}`))
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	},
+		WithMaxRetries(3),
+		WithRetryBackoff(time.Millisecond),
+		WithRetryErrorCodes(7),
+	)
+
+	_, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 error after retrying the overloaded chat, got %v", err)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected only the retry code to be retried for POST, got %d attempts", requests.Load())
+	}
+}
+
+func TestRetryErrorCodesNotConfigured(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, errorCodeHandler(&requests, 8, 1),
+		WithMaxRetries(2),
+		WithRetryBackoff(time.Millisecond),
+		WithRetryErrorCodes(7),
+	)
+
+	_, err := client.GetModels(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.Code != 8 || apiErr.Message != "Model is overloaded" {
+		t.Errorf("Expected code 8 with message from body, got code %d and message '%s'", apiErr.Code, apiErr.Message)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected request with other code not to be retried, got %d attempts", requests.Load())
+	}
+}
+
+func TestOnRetry(t *testing.T) {
+	var requests atomic.Int32
+	var (
+		attempts []int
+		delays   []time.Duration
+		errs     []error
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	},
+		WithMaxRetries(3),
+		WithRetryBackoff(time.Millisecond),
+		WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			attempts = append(attempts, attempt)
+			errs = append(errs, err)
+			delays = append(delays, delay)
+		}),
+	)
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Fatalf("Expected callback for attempts [1 2], got %v", attempts)
+	}
+
+	if delays[1] <= delays[0] {
+		t.Errorf("Expected increasing delays, got %v", delays)
+	}
+
+	for _, err := range errs {
+		if err == nil || err.Error() != "unexpected status 503" {
+			t.Errorf("Expected 'unexpected status 503' error, got %v", err)
+		}
+	}
+}
+
+func TestMaxRetryElapsed(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	},
+		WithMaxRetries(100),
+		WithRetryBackoff(10*time.Millisecond),
+		WithMaxRetryElapsed(150*time.Millisecond),
+	)
+
+	start := time.Now()
+	_, err := client.GetModels(context.Background())
+	elapsed := time.Since(start)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected last 503 error, got %v", err)
+	}
+
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Expected retries to stop near the 150ms cap, took %s", elapsed)
+	}
+
+	if got := requests.Load(); got < 2 || got > 5 {
+		t.Errorf("Expected a few attempts within the elapsed cap, got %d", got)
+	}
+}
+
+func TestRetryDelayCapped(t *testing.T) {
+	client := NewClient("key")
+
+	if delay := client.retryDelay(2, nil); delay != 4*defaultRetryBackoff {
+		t.Errorf("Expected exponential delay %s, got %s", 4*defaultRetryBackoff, delay)
+	}
+
+	for _, attempt := range []int{10, 35, 64, 1000} {
+		if delay := client.retryDelay(attempt, nil); delay != maxRetryBackoff {
+			t.Errorf("Expected delay for attempt %d to be capped at %s, got %s", attempt, maxRetryBackoff, delay)
+		}
+	}
+
+	client = NewClient("key", WithRetryBackoff(time.Minute))
+	if delay := client.retryDelay(5, nil); delay != time.Minute {
+		t.Errorf("Expected backoff above the cap to stay at %s, got %s", time.Minute, delay)
+	}
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ChatSession хранит историю многоходового диалога с моделью.
+// ChatSession не предназначен для конкурентного использования.
+type ChatSession struct {
+	client      *Client
+	model       string
+	messages    []ChatMessage
+	temperature *float64
+	topP        *float64
+	maxTokens   *int
+	maxHistory  int
+	usage       UsageTracker
+	// sessionID - идентификатор серверной сессии, полученный в ответе на первый Send
+	sessionID string
+}
+
+type SessionOption func(*ChatSession)
+
+func WithSystemPrompt(prompt string) SessionOption {
+	return func(s *ChatSession) {
+		s.messages = append(s.messages, ChatMessage{
+			Role:    RoleSystem,
+			Content: prompt,
+		})
+	}
+}
+
+func WithSessionTemperature(temperature float64) SessionOption {
+	return func(s *ChatSession) {
+		s.temperature = &temperature
+	}
+}
+
+func WithSessionTopP(topP float64) SessionOption {
+	return func(s *ChatSession) {
+		s.topP = &topP
+	}
+}
+
+func WithSessionMaxTokens(maxTokens int) SessionOption {
+	return func(s *ChatSession) {
+		s.maxTokens = &maxTokens
+	}
+}
+
+// WithMaxHistory ограничивает историю сессии последними n сообщениями.
+// Системное сообщение в начале истории сохраняется всегда и в n не учитывается.
+func WithMaxHistory(n int) SessionOption {
+	return func(s *ChatSession) {
+		s.maxHistory = n
+	}
+}
+
+// NewChatSession создает новую сессию диалога с указанной моделью
+func NewChatSession(c *Client, model string, opts ...SessionOption) *ChatSession {
+	s := &ChatSession{
+		client: c,
+		model:  model,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// LoadChatSession восстанавливает сессию, сохраненную через Save
+func LoadChatSession(c *Client, r io.Reader) (*ChatSession, error) {
+	s := &ChatSession{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("failed to decode chat session: %w", err)
+	}
+	s.client = c
+
+	return s, nil
+}
+
+// Model возвращает модель, используемую в сессии
+func (s *ChatSession) Model() string {
+	return s.model
+}
+
+// History возвращает копию истории сообщений сессии
+func (s *ChatSession) History() []ChatMessage {
+	history := make([]ChatMessage, len(s.messages))
+	copy(history, s.messages)
+	return history
+}
+
+// SessionID возвращает идентификатор серверной сессии (X-Session-ID), полученный
+// в ответе сервера. Пустая строка означает, что сервер его не возвращал.
+func (s *ChatSession) SessionID() string {
+	return s.sessionID
+}
+
+// TotalUsage возвращает суммарное использование токенов всеми запросами сессии
+func (s *ChatSession) TotalUsage() Usage {
+	return s.usage.Total()
+}
+
+// Send отправляет сообщение пользователя и добавляет ответ модели в историю
+func (s *ChatSession) Send(ctx context.Context, content string) (*ChatMessage, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("chat session has no client")
+	}
+
+	messages := s.trimHistory(append(s.History(), ChatMessage{
+		Role:    RoleUser,
+		Content: content,
+	}))
+
+	if _, ok := ctx.Value(responseInfoKey).(*responseInfo); !ok {
+		ctx = WithResponseCapture(ctx)
+	}
+	if s.sessionID != "" && sessionIDFromContext(ctx) == "" {
+		ctx = WithSessionID(ctx, s.sessionID)
+	}
+
+	resp, err := s.client.Chat(ctx, &ChatRequest{
+		Model:       s.model,
+		Messages:    messages,
+		Temperature: s.temperature,
+		TopP:        s.topP,
+		MaxTokens:   s.maxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from GigaChat")
+	}
+
+	s.usage.Add(resp.Usage)
+	if sessionID := ResponseSessionID(ctx); sessionID != "" {
+		s.sessionID = sessionID
+	}
+
+	reply := resp.Choices[0].Message
+	s.messages = s.trimHistory(append(messages, reply))
+
+	return &reply, nil
+}
+
+// Fork создает независимую копию сессии с той же историей и настройками,
+// что позволяет продолжить диалог в нескольких вариантах. Использование токенов
+// форка считается с нуля, чтобы суммы по веткам не учитывали общие запросы дважды.
+// Идентификатор сессии (SessionID) не копируется: сервер кэширует контекст сессии
+// для одной последовательной истории, поэтому форк получает новую сессию при первом Send.
+func (s *ChatSession) Fork() *ChatSession {
+	fork := &ChatSession{
+		client:      s.client,
+		model:       s.model,
+		messages:    make([]ChatMessage, len(s.messages)),
+		temperature: clonePtr(s.temperature),
+		topP:        clonePtr(s.topP),
+		maxTokens:   clonePtr(s.maxTokens),
+		maxHistory:  s.maxHistory,
+	}
+
+	for i, msg := range s.messages {
+		fork.messages[i] = msg.clone()
+	}
+
+	return fork
+}
+
+// clonePtr возвращает указатель на копию значения p
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// trimHistory оставляет в messages не более maxHistory последних сообщений,
+// сохраняя начальное системное сообщение
+func (s *ChatSession) trimHistory(messages []ChatMessage) []ChatMessage {
+	if s.maxHistory <= 0 {
+		return messages
+	}
+
+	var system []ChatMessage
+	rest := messages
+	if len(rest) > 0 && rest[0].Role == RoleSystem {
+		system, rest = rest[:1], rest[1:]
+	}
+
+	if len(rest) <= s.maxHistory {
+		return messages
+	}
+
+	return append(system, rest[len(rest)-s.maxHistory:]...)
+}
+
+// Save сохраняет сессию в w в формате JSON
+func (s *ChatSession) Save(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return fmt.Errorf("failed to encode chat session: %w", err)
+	}
+	return nil
+}
+
+// chatSessionState представляет сериализуемое состояние сессии
+type chatSessionState struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	MaxTokens   *int          `json:"max_tokens,omitempty"`
+	MaxHistory  int           `json:"max_history,omitempty"`
+	SessionID   string        `json:"session_id,omitempty"`
+}
+
+func (s *ChatSession) MarshalJSON() ([]byte, error) {
+	return json.Marshal(chatSessionState{
+		Model:       s.model,
+		Messages:    s.messages,
+		Temperature: s.temperature,
+		TopP:        s.topP,
+		MaxTokens:   s.maxTokens,
+		MaxHistory:  s.maxHistory,
+		SessionID:   s.sessionID,
+	})
+}
+
+func (s *ChatSession) UnmarshalJSON(data []byte) error {
+	var state chatSessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	s.model = state.Model
+	s.messages = state.Messages
+	s.temperature = state.Temperature
+	s.topP = state.TopP
+	s.maxTokens = state.MaxTokens
+	s.maxHistory = state.MaxHistory
+	s.sessionID = state.SessionID
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// echoChatHandler отвечает на запрос чата, повторяя последнее сообщение.
+// Если requests не nil, в него записываются полученные запросы.
+func echoChatHandler(t *testing.T, requests *[]ChatRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		if requests != nil {
+			*requests = append(*requests, req)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{
+			Choices: []ChatChoice{
+				{
+					Message: ChatMessage{
+						Role:    RoleAssistant,
+						Content: "reply to " + req.Messages[len(req.Messages)-1].Content,
+					},
+				},
+			},
+			Usage: Usage{
+				PromptTokens:     len(req.Messages),
+				CompletionTokens: 2,
+				TotalTokens:      len(req.Messages) + 2,
+			},
+		})
+	}
+}
+
+func TestChatSessionSaveLoad(t *testing.T) {
+	client := newTestClient(t, echoChatHandler(t, nil))
+
+	session := NewChatSession(client, "GigaChat:latest",
+		WithSystemPrompt("You are a helpful assistant"),
+		WithSessionTemperature(0.5),
+		WithSessionMaxTokens(100),
+	)
+
+	ctx := context.Background()
+	for _, content := range []string{"Hello", "How are you?"} {
+		if _, err := session.Send(ctx, content); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := session.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadChatSession(client, &buf)
+	if err != nil {
+		t.Fatalf("LoadChatSession failed: %v", err)
+	}
+
+	if len(loaded.History()) != 5 {
+		t.Errorf("Expected 5 messages in history, got %d", len(loaded.History()))
+	}
+
+	if !reflect.DeepEqual(loaded.History(), session.History()) {
+		t.Errorf("Expected history %v, got %v", session.History(), loaded.History())
+	}
+
+	if loaded.Model() != "GigaChat:latest" {
+		t.Errorf("Expected model to be 'GigaChat:latest', got '%s'", loaded.Model())
+	}
+
+	if loaded.temperature == nil || *loaded.temperature != 0.5 {
+		t.Errorf("Expected temperature to be 0.5, got %v", loaded.temperature)
+	}
+
+	if loaded.maxTokens == nil || *loaded.maxTokens != 100 {
+		t.Errorf("Expected max tokens to be 100, got %v", loaded.maxTokens)
+	}
+
+	if _, err := loaded.Send(ctx, "Still there?"); err != nil {
+		t.Fatalf("Send after load failed: %v", err)
+	}
+
+	if len(loaded.History()) != 7 {
+		t.Errorf("Expected 7 messages after resume, got %d", len(loaded.History()))
+	}
+}
+
+func TestChatSessionMaxHistory(t *testing.T) {
+	var requests []ChatRequest
+	client := newTestClient(t, echoChatHandler(t, &requests))
+
+	session := NewChatSession(client, "GigaChat:latest",
+		WithSystemPrompt("You are a helpful assistant"),
+		WithMaxHistory(4),
+	)
+
+	ctx := context.Background()
+	for i := range 10 {
+		if _, err := session.Send(ctx, fmt.Sprintf("message %d", i)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+
+		history := session.History()
+		if len(history) > 5 {
+			t.Fatalf("Expected at most 5 messages in history, got %d", len(history))
+		}
+		if history[0].Role != RoleSystem {
+			t.Fatalf("Expected system message to be preserved, got role '%s'", history[0].Role)
+		}
+	}
+
+	last := requests[len(requests)-1]
+	if len(last.Messages) != 5 {
+		t.Errorf("Expected 5 messages in the last request, got %d", len(last.Messages))
+	}
+
+	if last.Messages[0].Role != RoleSystem {
+		t.Errorf("Expected first message of the request to be system, got '%s'", last.Messages[0].Role)
+	}
+
+	if last.Messages[4].Content != "message 9" {
+		t.Errorf("Expected last message to be 'message 9', got '%s'", last.Messages[4].Content)
+	}
+}
+
+func TestChatSessionTotalUsage(t *testing.T) {
+	client := newTestClient(t, echoChatHandler(t, nil))
+	session := NewChatSession(client, "GigaChat:latest")
+
+	for _, content := range []string{"first", "second"} {
+		if _, err := session.Send(context.Background(), content); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	// Первый запрос содержит 1 сообщение, второй - 3
+	expected := Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8}
+	if got := session.TotalUsage(); got != expected {
+		t.Errorf("Expected total usage %+v, got %+v", expected, got)
+	}
+}
+
+func TestChatSessionFork(t *testing.T) {
+	client := newTestClient(t, echoChatHandler(t, nil))
+	parent := NewChatSession(client, "GigaChat:latest",
+		WithSystemPrompt("You are a helpful assistant"),
+		WithSessionTemperature(0.5),
+	)
+
+	if _, err := parent.Send(context.Background(), "Hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	fork := parent.Fork()
+	if _, err := fork.Send(context.Background(), "Branch"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	*fork.temperature = 0.9
+
+	if got := len(parent.History()); got != 3 {
+		t.Errorf("Expected parent history to stay at 3 messages, got %d", got)
+	}
+
+	if got := len(fork.History()); got != 5 {
+		t.Errorf("Expected fork history to have 5 messages, got %d", got)
+	}
+
+	if *parent.temperature != 0.5 {
+		t.Errorf("Expected parent temperature to stay 0.5, got %v", *parent.temperature)
+	}
+
+	if fork.Model() != parent.Model() {
+		t.Errorf("Expected fork model '%s', got '%s'", parent.Model(), fork.Model())
+	}
+}
+
+func TestChatSessionSessionID(t *testing.T) {
+	var sessionIDs []string
+	echo := echoChatHandler(t, nil)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sessionIDs = append(sessionIDs, r.Header.Get("X-Session-ID"))
+		w.Header().Set("X-Session-ID", "session-1")
+		echo(w, r)
+	})
+	session := NewChatSession(client, "GigaChat:latest")
+
+	for _, content := range []string{"Hello", "How are you?"} {
+		if _, err := session.Send(context.Background(), content); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	if sessionIDs[0] != "" {
+		t.Errorf("Expected first Send without X-Session-ID, got '%s'", sessionIDs[0])
+	}
+
+	if sessionIDs[1] != "session-1" {
+		t.Errorf("Expected second Send to reuse X-Session-ID 'session-1', got '%s'", sessionIDs[1])
+	}
+
+	var buf bytes.Buffer
+	if err := session.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadChatSession(client, &buf)
+	if err != nil {
+		t.Fatalf("LoadChatSession failed: %v", err)
+	}
+	if loaded.SessionID() != "session-1" {
+		t.Errorf("Expected loaded session to keep session ID 'session-1', got '%s'", loaded.SessionID())
+	}
+
+	if fork := session.Fork(); fork.SessionID() != "" {
+		t.Errorf("Expected fork to start a new server session, got session ID '%s'", fork.SessionID())
+	}
+}
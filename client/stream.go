@@ -0,0 +1,395 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const defaultStreamBufferSize = 64 * 1024
+
+var (
+	// ErrStreamEventTooLarge возвращается, если событие потока превышает размер буфера
+	ErrStreamEventTooLarge = errors.New("stream event too large")
+	// ErrStreamStalled возвращается, если поток не присылает данных дольше WithStreamHeartbeatTimeout
+	ErrStreamStalled = errors.New("stream stalled")
+)
+
+// ChatStreamReader читает потоковый ответ чата в формате SSE
+type ChatStreamReader struct {
+	client     *Client
+	resp       *http.Response
+	scanner    *bufio.Scanner
+	bufferSize int
+	cancel     context.CancelFunc
+	aborted    atomic.Bool
+	stalled    atomic.Bool
+	heartbeat  *time.Timer
+	// functionName хранит имя вызываемой функции из первого фрагмента, в котором оно пришло
+	functionName atomic.Pointer[string]
+}
+
+// heartbeatReader запускает таймер ожидания только на время блокирующего чтения,
+// чтобы медленная обработка фрагментов между вызовами Recv не считалась остановкой потока
+type heartbeatReader struct {
+	io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *heartbeatReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	defer r.timer.Stop()
+	return r.Reader.Read(p)
+}
+
+// ChatStream выполняет потоковый запрос к чату
+func (c *Client) ChatStream(ctx context.Context, req *ChatRequest) (*ChatStreamReader, error) {
+	streamReq, err := c.prepareChatRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := true
+	streamReq.Stream = &stream
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	httpReq, err := c.newRequest(ctx, "POST", c.chatPath, streamReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.do(ctx, httpReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, c.newAPIError("chat stream", resp)
+	}
+
+	r := &ChatStreamReader{
+		client:     c,
+		resp:       resp,
+		bufferSize: c.streamBufferSize,
+		cancel:     cancel,
+	}
+
+	var body io.Reader = resp.Body
+	if c.streamHeartbeatTimeout > 0 {
+		r.heartbeat = time.AfterFunc(c.streamHeartbeatTimeout, func() {
+			r.stalled.Store(true)
+			cancel()
+		})
+		r.heartbeat.Stop()
+		body = &heartbeatReader{
+			Reader:  resp.Body,
+			timer:   r.heartbeat,
+			timeout: c.streamHeartbeatTimeout,
+		}
+	}
+
+	r.scanner = bufio.NewScanner(body)
+	r.scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, c.streamBufferSize)), c.streamBufferSize)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		cancel()
+		resp.Body.Close()
+		return nil, ErrClientClosed
+	}
+	if c.streams == nil {
+		c.streams = make(map[*ChatStreamReader]struct{})
+	}
+	c.streams[r] = struct{}{}
+
+	return r, nil
+}
+
+// Recv возвращает очередной фрагмент ответа или io.EOF по завершении потока
+func (r *ChatStreamReader) Recv() (*ChatResponse, error) {
+	for {
+		data, ok, err := r.nextEvent()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		if strings.TrimSpace(data) == "[DONE]" {
+			r.stopHeartbeat()
+			return nil, io.EOF
+		}
+
+		if err := r.errorFrame(data); err != nil {
+			return nil, err
+		}
+
+		var chunk ChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		r.recordFunctionName(&chunk)
+
+		return &chunk, nil
+	}
+
+	if r.aborted.Load() {
+		return nil, ErrClientClosed
+	}
+	if r.stalled.Load() {
+		return nil, ErrStreamStalled
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, r.eventTooLarge()
+		}
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	r.stopHeartbeat()
+	return nil, io.EOF
+}
+
+// stopHeartbeat останавливает таймер ожидания данных по завершении потока
+func (r *ChatStreamReader) stopHeartbeat() {
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+	}
+}
+
+// FunctionName возвращает имя функции, вызов которой запрашивает модель, как только
+// оно получено в потоке, не дожидаясь аргументов. До этого возвращается пустая строка.
+func (r *ChatStreamReader) FunctionName() string {
+	if name := r.functionName.Load(); name != nil {
+		return *name
+	}
+	return ""
+}
+
+// recordFunctionName запоминает первое имя функции, полученное во фрагменте
+func (r *ChatStreamReader) recordFunctionName(chunk *ChatResponse) {
+	if r.functionName.Load() != nil {
+		return
+	}
+	for _, choice := range chunk.Choices {
+		if call := choice.Delta.FunctionCall; call != nil && call.Name != "" {
+			r.functionName.Store(&call.Name)
+			return
+		}
+	}
+}
+
+// errorFrame возвращает APIError, если событие потока содержит ошибку вида
+// {"status": ..., "message": ...} вместо фрагмента ответа
+func (r *ChatStreamReader) errorFrame(data string) error {
+	var frame struct {
+		errorEnvelope
+		Choices json.RawMessage `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(data), &frame); err != nil {
+		return nil
+	}
+	if frame.Choices != nil || (frame.Status == 0 && frame.Message == "") {
+		return nil
+	}
+
+	apiErr := &APIError{
+		Op:         "chat stream",
+		StatusCode: frame.Status,
+		Body:       data,
+	}
+	if apiErr.StatusCode == 0 {
+		apiErr.StatusCode = r.resp.StatusCode
+	}
+	frame.errorEnvelope.applyTo(apiErr)
+
+	return apiErr
+}
+
+// nextEvent возвращает данные очередного события SSE. Несколько строк data: одного
+// события объединяются через перевод строки; события без данных пропускаются.
+// Размер объединенных данных ограничен размером буфера потока.
+func (r *ChatStreamReader) nextEvent() (string, bool, error) {
+	var (
+		lines []string
+		size  int
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if len(lines) > 0 {
+				return strings.Join(lines, "\n"), true, nil
+			}
+			continue
+		}
+
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimPrefix(data, " ")
+
+		size += len(data)
+		if len(lines) > 0 {
+			size++
+		}
+		if size > r.bufferSize {
+			return "", false, r.eventTooLarge()
+		}
+		lines = append(lines, data)
+	}
+
+	// Событие в конце потока без завершающей пустой строки
+	if len(lines) > 0 && r.scanner.Err() == nil {
+		return strings.Join(lines, "\n"), true, nil
+	}
+
+	return "", false, nil
+}
+
+// eventTooLarge возвращает ошибку для события, превышающего размер буфера потока
+func (r *ChatStreamReader) eventTooLarge() error {
+	return fmt.Errorf("%w: exceeds %d bytes", ErrStreamEventTooLarge, r.bufferSize)
+}
+
+// RecvAll читает поток до конца и собирает из фрагментов полный ответ.
+// Фрагменты разных вариантов ответа (при N > 1) собираются по их индексу.
+func (r *ChatStreamReader) RecvAll() (*ChatResponse, error) {
+	return r.recvAll(nil)
+}
+
+// recvAll собирает полный ответ как RecvAll, передавая непустой текст каждого фрагмента в onDelta
+func (r *ChatStreamReader) recvAll(onDelta func(choiceIndex int, chunk string)) (*ChatResponse, error) {
+	var resp ChatResponse
+	choices := make(map[int]*ChatChoice)
+
+	for {
+		chunk, err := r.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.ID != "" {
+			resp.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Created != 0 {
+			resp.Created = chunk.Created
+		}
+		if chunk.Usage != (Usage{}) {
+			resp.Usage = chunk.Usage
+		}
+
+		for _, delta := range chunk.Choices {
+			choice, ok := choices[delta.Index]
+			if !ok {
+				choice = &ChatChoice{Index: delta.Index}
+				choices[delta.Index] = choice
+			}
+
+			choice.Message.AppendDelta(delta.Delta)
+			if onDelta != nil && delta.Delta.Content != "" {
+				onDelta(delta.Index, delta.Delta.Content)
+			}
+			if delta.FinishReason != "" {
+				choice.FinishReason = delta.FinishReason
+			}
+		}
+	}
+
+	resp.Object = "chat.completion"
+	resp.Choices = make([]ChatChoice, 0, len(choices))
+	for _, index := range slices.Sorted(maps.Keys(choices)) {
+		resp.Choices = append(resp.Choices, *choices[index])
+	}
+
+	return &resp, nil
+}
+
+// AppendDelta дополняет сообщение фрагментом потокового ответа: текст дописывается,
+// роль и имя функции сохраняются, если во фрагменте их нет, аргументы функции берутся из фрагмента
+func (m *ChatMessage) AppendDelta(delta ChatMessage) {
+	if delta.Role != "" {
+		m.Role = delta.Role
+	}
+	m.Content += delta.Content
+
+	if delta.FunctionCall == nil {
+		return
+	}
+	if m.FunctionCall == nil {
+		m.FunctionCall = &FunctionCall{}
+	}
+	if delta.FunctionCall.Name != "" {
+		m.FunctionCall.Name = delta.FunctionCall.Name
+	}
+	if delta.FunctionCall.Arguments != nil {
+		m.FunctionCall.Arguments = delta.FunctionCall.Arguments
+	}
+}
+
+// ChatStreamFunc выполняет потоковый запрос к чату, передавая в fn текст каждого фрагмента
+// первого варианта ответа, и возвращает собранный ответ, как RecvAll
+func (c *Client) ChatStreamFunc(ctx context.Context, req *ChatRequest, fn func(chunk string)) (*ChatResponse, error) {
+	return c.ChatStreamChoicesFunc(ctx, req, func(choiceIndex int, chunk string) {
+		if choiceIndex == 0 {
+			fn(chunk)
+		}
+	})
+}
+
+// ChatStreamChoicesFunc работает как ChatStreamFunc, но передает в fn фрагменты всех
+// вариантов ответа (при N > 1) вместе с индексом варианта
+func (c *Client) ChatStreamChoicesFunc(
+	ctx context.Context, req *ChatRequest, fn func(choiceIndex int, chunk string),
+) (*ChatResponse, error) {
+	stream, err := c.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	return stream.recvAll(fn)
+}
+
+// Close закрывает поток
+func (r *ChatStreamReader) Close() error {
+	r.client.mu.Lock()
+	delete(r.client.streams, r)
+	r.client.mu.Unlock()
+
+	r.stopHeartbeat()
+	r.cancel()
+	return r.resp.Body.Close()
+}
+
+// abort прерывает поток при закрытии клиента
+func (r *ChatStreamReader) abort() {
+	r.aborted.Store(true)
+	r.cancel()
+}
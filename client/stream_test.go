@@ -0,0 +1,491 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSSE отправляет клиенту события в формате SSE
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		fmt.Fprintf(w, "data: %s\n\n", event)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+			"[DONE]",
+		)
+	})
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content += chunk.Choices[0].Delta.Content
+	}
+
+	if content != "Hello" {
+		t.Errorf("Expected content to be 'Hello', got '%s'", content)
+	}
+}
+
+func TestChatStreamCompression(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		zw := gzip.NewWriter(w)
+		fmt.Fprint(zw, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(zw, "data: [DONE]\n\n")
+		zw.Close()
+	}, WithCompression())
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+
+	if chunk.Choices[0].Delta.Content != "Hi" {
+		t.Errorf("Expected content to be 'Hi', got '%s'", chunk.Choices[0].Delta.Content)
+	}
+
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestChatStreamBufferSize(t *testing.T) {
+	const limit = 1024
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "below limit", size: limit / 2},
+		{name: "above limit", size: limit * 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := fmt.Sprintf(`{"choices":[{"index":0,"delta":{"content":"%s"}}]}`, strings.Repeat("a", tt.size))
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeSSE(w, event, "[DONE]")
+			}, WithStreamBufferSize(limit))
+
+			stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+			if err != nil {
+				t.Fatalf("ChatStream failed: %v", err)
+			}
+			defer stream.Close()
+
+			chunk, err := stream.Recv()
+			if tt.wantErr {
+				if !errors.Is(err, ErrStreamEventTooLarge) {
+					t.Errorf("Expected ErrStreamEventTooLarge, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Recv failed: %v", err)
+			}
+			if len(chunk.Choices[0].Delta.Content) != tt.size {
+				t.Errorf("Expected content of %d bytes, got %d", tt.size, len(chunk.Choices[0].Delta.Content))
+			}
+		})
+	}
+}
+
+func TestChatStreamMultiLineEventTooLarge(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]\n")
+		for range 100 {
+			fmt.Fprintf(w, "data: ,%q: 0\n", strings.Repeat("x", 40))
+		}
+		fmt.Fprint(w, "data: }\n\n")
+	}, WithStreamBufferSize(1024))
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); !errors.Is(err, ErrStreamEventTooLarge) {
+		t.Errorf("Expected ErrStreamEventTooLarge for an event split into short lines, got %v", err)
+	}
+}
+
+func TestChatStreamClientClose(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, `{"choices":[{"index":0,"delta":{"content":"Hel"}}]}`)
+		<-r.Context().Done()
+	})
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { client.Close() })
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := stream.Recv()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("Expected ErrClientClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Recv did not return after Close")
+	}
+
+	if _, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed for new stream, got %v", err)
+	}
+}
+
+func TestChatStreamHeartbeatTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, `{"choices":[{"index":0,"delta":{"content":"Hel"}}]}`)
+		<-r.Context().Done()
+	}, WithStreamHeartbeatTimeout(100*time.Millisecond))
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err = stream.Recv()
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrStreamStalled) {
+		t.Errorf("Expected ErrStreamStalled, got %v", err)
+	}
+
+	if elapsed > time.Second {
+		t.Errorf("Expected stall to be detected after ~100ms, took %s", elapsed)
+	}
+}
+
+func TestChatStreamHeartbeatSlowConsumer(t *testing.T) {
+	const events = 500
+	payload := strings.Repeat("x", 2048)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for range events {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", payload)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}, WithStreamHeartbeatTimeout(100*time.Millisecond))
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	received := 0
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed after %d chunks: %v", received, err)
+		}
+		received++
+		if received == 1 {
+			// Пока вызывающий обрабатывает фрагмент, поток не должен считаться остановленным
+			time.Sleep(300 * time.Millisecond)
+		}
+	}
+
+	if received != events {
+		t.Errorf("Expected %d chunks, got %d", events, received)
+	}
+}
+
+func TestChatStreamRecvAllMultipleChoices(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"id":"1","model":"GigaChat","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"id":"1","model":"GigaChat","choices":[{"index":1,"delta":{"role":"assistant","content":"Goo"}}]}`,
+			`{"id":"1","model":"GigaChat","choices":[{"index":1,"delta":{"content":"d day"},"finish_reason":"length"}]}`,
+			`{"id":"1","model":"GigaChat","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}],"usage":{"total_tokens":7}}`,
+			"[DONE]",
+		)
+	})
+
+	n := 2
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest", N: &n})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := stream.RecvAll()
+	if err != nil {
+		t.Fatalf("RecvAll failed: %v", err)
+	}
+
+	if len(resp.Choices) != 2 {
+		t.Fatalf("Expected 2 choices, got %d", len(resp.Choices))
+	}
+
+	expected := []string{"Hello", "Good day"}
+	reasons := []FinishReason{"stop", "length"}
+	for i, choice := range resp.Choices {
+		if choice.Index != i {
+			t.Errorf("Expected choice %d to have index %d, got %d", i, i, choice.Index)
+		}
+		if choice.Message.Content != expected[i] {
+			t.Errorf("Expected choice %d content to be '%s', got '%s'", i, expected[i], choice.Message.Content)
+		}
+		if choice.Message.Role != RoleAssistant {
+			t.Errorf("Expected choice %d role to be assistant, got '%s'", i, choice.Message.Role)
+		}
+		if choice.FinishReason != reasons[i] {
+			t.Errorf("Expected choice %d finish reason to be '%s', got '%s'", i, reasons[i], choice.FinishReason)
+		}
+	}
+
+	if resp.ID != "1" || resp.Model != "GigaChat" {
+		t.Errorf("Expected response metadata to be set, got id '%s' model '%s'", resp.ID, resp.Model)
+	}
+
+	if resp.Usage.TotalTokens != 7 {
+		t.Errorf("Expected total tokens to be 7, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestChatStreamMultilineData(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\n")
+		fmt.Fprint(w, "data: \"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+
+	if chunk.Choices[0].Delta.Content != "Hi" {
+		t.Errorf("Expected joined event content to be 'Hi', got '%s'", chunk.Choices[0].Delta.Content)
+	}
+
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestChatStreamErrorFrame(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"status\":500,\"message\":\"Internal model error\"}\n\n")
+	})
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+
+	_, err = stream.Recv()
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+
+	if apiErr.Op != "chat stream" || apiErr.StatusCode != 500 || apiErr.Message != "Internal model error" {
+		t.Errorf("Expected chat stream error 500 'Internal model error', got %+v", apiErr)
+	}
+}
+
+// interleavedChoicesHandler отдает перемежающиеся фрагменты двух вариантов ответа
+func interleavedChoicesHandler(w http.ResponseWriter, r *http.Request) {
+	writeSSE(w,
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"choices":[{"index":1,"delta":{"role":"assistant","content":"Goo"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"lo"}},{"index":1,"delta":{"content":"d day"}}]}`,
+		"[DONE]",
+	)
+}
+
+func TestChatStreamChoicesFunc(t *testing.T) {
+	client := newTestClient(t, interleavedChoicesHandler)
+
+	var got []string
+	resp, err := client.ChatStreamChoicesFunc(context.Background(), &ChatRequest{Model: "GigaChat:latest", N: ptr(2)},
+		func(choiceIndex int, chunk string) {
+			got = append(got, fmt.Sprintf("%d:%s", choiceIndex, chunk))
+		})
+	if err != nil {
+		t.Fatalf("ChatStreamChoicesFunc failed: %v", err)
+	}
+
+	expected := []string{"0:Hel", "1:Goo", "0:lo", "1:d day"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected callbacks %v, got %v", expected, got)
+	}
+
+	if len(resp.Choices) != 2 || resp.Choices[1].Message.Content != "Good day" {
+		t.Errorf("Expected assembled response with 2 choices, got %+v", resp.Choices)
+	}
+}
+
+func TestChatStreamFunc(t *testing.T) {
+	client := newTestClient(t, interleavedChoicesHandler)
+
+	var got []string
+	resp, err := client.ChatStreamFunc(context.Background(), &ChatRequest{Model: "GigaChat:latest", N: ptr(2)},
+		func(chunk string) {
+			got = append(got, chunk)
+		})
+	if err != nil {
+		t.Fatalf("ChatStreamFunc failed: %v", err)
+	}
+
+	if !slices.Equal(got, []string{"Hel", "lo"}) {
+		t.Errorf("Expected first choice chunks [Hel lo], got %v", got)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello" {
+		t.Errorf("Expected first choice content 'Hello', got '%s'", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestChatStreamFunctionName(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+			`{"choices":[{"index":0,"delta":{"function_call":{"name":"get_weather"}}}]}`,
+			`{"choices":[{"index":0,"delta":{"function_call":{"arguments":{"city":"Moscow"}}}}]}`,
+			"[DONE]",
+		)
+	})
+
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{Model: "GigaChat:latest"})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if name := stream.FunctionName(); name != "" {
+		t.Errorf("Expected no function name before it arrives, got '%s'", name)
+	}
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if chunk.Choices[0].Delta.FunctionCall.Arguments != nil {
+		t.Fatal("Expected arguments to be incomplete in the name chunk")
+	}
+	if name := stream.FunctionName(); name != "get_weather" {
+		t.Errorf("Expected function name 'get_weather' before arguments, got '%s'", name)
+	}
+
+	if _, err := stream.RecvAll(); err != nil {
+		t.Fatalf("RecvAll failed: %v", err)
+	}
+	if name := stream.FunctionName(); name != "get_weather" {
+		t.Errorf("Expected function name to survive the arguments chunk, got '%s'", name)
+	}
+
+	resp, err := client.ChatStreamFunc(context.Background(), &ChatRequest{Model: "GigaChat:latest"}, func(string) {})
+	if err != nil {
+		t.Fatalf("ChatStreamFunc failed: %v", err)
+	}
+	if call := resp.Choices[0].Message.FunctionCall; call == nil || call.Name != "get_weather" || call.Arguments["city"] != "Moscow" {
+		t.Errorf("Expected name from the name chunk and arguments from the last, got %+v", call)
+	}
+}
+
+func TestChatStreamFinishReason(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Long"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":" answer"},"finish_reason":"length"}]}`,
+			"[DONE]",
+		)
+	})
+
+	resp, err := client.ChatStreamFunc(context.Background(), &ChatRequest{Model: "GigaChat:latest"}, func(string) {})
+	if err != nil {
+		t.Fatalf("ChatStreamFunc failed: %v", err)
+	}
+
+	reason := resp.Choices[0].FinishReason
+	if reason != FinishLength || !reason.IsLength() || reason.IsStop() {
+		t.Errorf("Expected streamed finish reason to decode as FinishLength, got %q", reason)
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tokenClaims содержит сведения из полезной нагрузки JWT токена доступа
+type tokenClaims struct {
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// parseTokenClaims разбирает полезную нагрузку JWT без проверки подписи.
+// Для токенов, не являющихся JWT, возвращается false.
+func parseTokenClaims(token string) (tokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return tokenClaims{}, false
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, false
+	}
+
+	return claims, true
+}
+
+// checkTokenClaims сверяет сведения JWT с запрошенной областью доступа и возвращает
+// время истечения токена: из JWT, если оно раньше указанного сервером expiry
+func checkTokenClaims(claims tokenClaims, scope Scope, expiry time.Time) (time.Time, error) {
+	if claims.Scope != "" && !slices.Contains(strings.Fields(claims.Scope), string(scope)) {
+		return time.Time{}, fmt.Errorf("access token scope %q does not include requested scope %s", claims.Scope, scope)
+	}
+
+	if claims.ExpiresAt > 0 {
+		if exp := time.Unix(claims.ExpiresAt, 0); exp.Before(expiry) {
+			return exp, nil
+		}
+	}
+
+	return expiry, nil
+}
+
+// readTokenFile читает токен доступа из JSON файла в формате ответа авторизации
+func readTokenFile(path string) (*TokenResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var tokenResp TokenResponse
+	if err := json.Unmarshal(data, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token file %s: %w", path, err)
+	}
+
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token file %s has no access_token", path)
+	}
+
+	return &tokenResp, nil
+}
+
+// TokenScope возвращает область доступа текущего токена, указанную в нем самом.
+// Пустая строка означает, что токен еще не получен или не содержит области доступа.
+func (c *Client) TokenScope() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
+	return c.tokenScope
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testJWT собирает неподписанный JWT с указанной полезной нагрузкой
+func testJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Failed to encode claims: %v", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	return fmt.Sprintf("%s.%s.sig", header, base64.RawURLEncoding.EncodeToString(payload))
+}
+
+func TestTokenScope(t *testing.T) {
+	exp := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	token := testJWT(t, map[string]any{"scope": "GIGACHAT_API_PERS", "exp": exp.Unix()})
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: token,
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	client := srv.client()
+
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if got := client.TokenScope(); got != "GIGACHAT_API_PERS" {
+		t.Errorf("Expected scope 'GIGACHAT_API_PERS', got '%s'", got)
+	}
+
+	if _, expiry := client.token(); !expiry.Equal(exp) {
+		t.Errorf("Expected expiry from JWT %s, got %s", exp, expiry)
+	}
+}
+
+func TestTokenScopeMismatch(t *testing.T) {
+	token := testJWT(t, map[string]any{"scope": "GIGACHAT_API_B2B"})
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	srv.authHandler = func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: token,
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	if err := srv.client().GetAccessToken(context.Background(), GIGACHAT_API_PERS); err == nil {
+		t.Error("Expected error for a token with a different scope")
+	}
+}
+
+func TestOpaqueToken(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	client := srv.client()
+
+	if err := client.GetAccessToken(context.Background(), GIGACHAT_API_PERS); err != nil {
+		t.Fatalf("GetAccessToken failed: %v", err)
+	}
+
+	if got := client.TokenScope(); got != "" {
+		t.Errorf("Expected empty scope for an opaque token, got '%s'", got)
+	}
+}
+
+// writeTokenFile сохраняет токен во временный файл и возвращает путь к нему
+func writeTokenFile(t *testing.T, token string, expiry time.Time) string {
+	t.Helper()
+
+	data, err := json.Marshal(TokenResponse{AccessToken: token, ExpiresAt: expiry.Unix()})
+	if err != nil {
+		t.Fatalf("Failed to encode token: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	return path
+}
+
+func TestInitialTokenFile(t *testing.T) {
+	var header string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	})
+
+	path := writeTokenFile(t, "preloaded_token", time.Now().Add(time.Hour))
+	client := srv.client(WithInitialTokenFile(path))
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if header != "Bearer preloaded_token" {
+		t.Errorf("Expected Authorization to be 'Bearer preloaded_token', got '%s'", header)
+	}
+
+	if srv.authRequests.Load() != 0 {
+		t.Errorf("Expected no auth requests with preloaded token, got %d", srv.authRequests.Load())
+	}
+}
+
+func TestInitialTokenFileExpired(t *testing.T) {
+	var header string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(ModelsResponse{})
+	})
+
+	path := writeTokenFile(t, "expired_token", time.Now().Add(-time.Minute))
+	client := srv.client(WithInitialTokenFile(path))
+
+	if _, err := client.GetModels(context.Background()); err != nil {
+		t.Fatalf("GetModels failed: %v", err)
+	}
+
+	if header != "Bearer test_token_1" {
+		t.Errorf("Expected expired token to be refreshed, got '%s'", header)
+	}
+}
+
+func TestInitialTokenFileInvalid(t *testing.T) {
+	client := NewClient("test_auth_key", WithInitialTokenFile(filepath.Join(t.TempDir(), "missing.json")))
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Error("Expected error for missing token file")
+	}
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := os.WriteFile(path, []byte(`{"expires_at": 1}`), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	client = NewClient("test_auth_key", WithInitialTokenFile(path))
+	if _, err := client.GetModels(context.Background()); err == nil {
+		t.Error("Expected error for token file without access_token")
+	}
+}
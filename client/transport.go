@@ -0,0 +1,73 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// applyTransportEditors применяет к транспорту HTTP клиента изменения, заданные опциями.
+// Транспорт и HTTP клиент копируются, чтобы не затронуть объекты, переданные пользователем.
+func (c *Client) applyTransportEditors() {
+	if len(c.transportEditors) == 0 {
+		return
+	}
+
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		c.invalidOption("transport options require *http.Transport, got %T", base)
+		return
+	}
+
+	transport = transport.Clone()
+	for _, edit := range c.transportEditors {
+		edit(transport)
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+}
+
+// WithTLSServerName задает имя сервера (SNI), передаваемое при TLS рукопожатии.
+// Нужно, если за прокси или при split-DNS имя для TLS отличается от адреса подключения.
+func WithTLSServerName(name string) Option {
+	return func(c *Client) {
+		if name == "" {
+			c.invalidOption("TLS server name must not be empty")
+			return
+		}
+		c.transportEditors = append(c.transportEditors, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.ServerName = name
+		})
+	}
+}
+
+// WithHTTP2Disabled принудительно использует HTTP/1.1. Помогает, если прокси некорректно
+// обрабатывает HTTP/2 и соединения зависают. Остальные настройки транспорта сохраняются.
+func WithHTTP2Disabled() Option {
+	return func(c *Client) {
+		c.transportEditors = append(c.transportEditors, func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = false
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		})
+	}
+}
+
+// WithDisableKeepAlives отключает повторное использование соединений. Помогает при
+// нестабильной работе прокси, но каждый запрос требует нового соединения и TLS рукопожатия,
+// что заметно увеличивает задержку.
+func WithDisableKeepAlives() Option {
+	return func(c *Client) {
+		c.transportEditors = append(c.transportEditors, func(t *http.Transport) {
+			t.DisableKeepAlives = true
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestWithTLSServerName(t *testing.T) {
+	userTransport := &http.Transport{TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	userClient := &http.Client{Transport: userTransport}
+
+	client := NewClient("key", WithHTTPClient(userClient), WithTLSServerName("gigachat.internal"))
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected *http.Transport, got %T", client.httpClient.Transport)
+	}
+
+	if transport.TLSClientConfig.ServerName != "gigachat.internal" {
+		t.Errorf("Expected ServerName to be 'gigachat.internal', got '%s'", transport.TLSClientConfig.ServerName)
+	}
+
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected other TLS settings to be preserved, got MinVersion %d", transport.TLSClientConfig.MinVersion)
+	}
+
+	if userTransport.TLSClientConfig.ServerName != "" {
+		t.Error("Expected user transport not to be modified")
+	}
+}
+
+func TestTransportOptionRequiresHTTPTransport(t *testing.T) {
+	client := NewClient("key",
+		WithHTTPClient(&http.Client{Transport: http.NewFileTransport(http.Dir("."))}),
+		WithTLSServerName("gigachat.internal"),
+	)
+
+	if client.configErr == nil {
+		t.Error("Expected config error for non-*http.Transport transport")
+	}
+}
+
+func TestWithHTTP2Disabled(t *testing.T) {
+	client := NewClient("key", WithHTTP2Disabled(), WithTLSServerName("gigachat.internal"))
+
+	transport := client.httpClient.Transport.(*http.Transport)
+	if transport.TLSNextProto == nil || len(transport.TLSNextProto) != 0 {
+		t.Errorf("Expected empty non-nil TLSNextProto, got %v", transport.TLSNextProto)
+	}
+
+	if transport.ForceAttemptHTTP2 {
+		t.Error("Expected ForceAttemptHTTP2 to be disabled")
+	}
+
+	if transport.TLSClientConfig.ServerName != "gigachat.internal" {
+		t.Errorf("Expected other transport options to be preserved, got ServerName '%s'", transport.TLSClientConfig.ServerName)
+	}
+}
+
+func TestWithDisableKeepAlives(t *testing.T) {
+	userTransport := &http.Transport{MaxIdleConns: 7}
+	client := NewClient("key", WithHTTPClient(&http.Client{Transport: userTransport}), WithDisableKeepAlives())
+
+	transport := client.httpClient.Transport.(*http.Transport)
+	if !transport.DisableKeepAlives {
+		t.Error("Expected keep-alives to be disabled")
+	}
+
+	if transport.MaxIdleConns != 7 {
+		t.Errorf("Expected other transport settings to be preserved, got MaxIdleConns %d", transport.MaxIdleConns)
+	}
+
+	if userTransport.DisableKeepAlives {
+		t.Error("Expected user transport not to be modified")
+	}
+}
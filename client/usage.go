@@ -0,0 +1,36 @@
+package client
+
+import "sync"
+
+// Add возвращает сумму использования токенов u и other
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:          u.PromptTokens + other.PromptTokens,
+		CompletionTokens:      u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:           u.TotalTokens + other.TotalTokens,
+		PrecachedPromptTokens: u.PrecachedPromptTokens + other.PrecachedPromptTokens,
+	}
+}
+
+// UsageTracker суммирует использование токенов по нескольким запросам.
+// Нулевое значение готово к использованию; методы безопасны для конкурентного вызова.
+type UsageTracker struct {
+	mu    sync.Mutex
+	total Usage
+}
+
+// Add добавляет использование токенов одного запроса
+func (t *UsageTracker) Add(u Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total = t.total.Add(u)
+}
+
+// Total возвращает суммарное использование токенов
+func (t *UsageTracker) Total() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.total
+}
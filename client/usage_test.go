@@ -0,0 +1,25 @@
+package client
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUsageTracker(t *testing.T) {
+	var tracker UsageTracker
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Add(Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5, PrecachedPromptTokens: 1})
+		}()
+	}
+	wg.Wait()
+
+	expected := Usage{PromptTokens: 30, CompletionTokens: 20, TotalTokens: 50, PrecachedPromptTokens: 10}
+	if got := tracker.Total(); got != expected {
+		t.Errorf("Expected total usage %+v, got %+v", expected, got)
+	}
+}
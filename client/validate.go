@@ -0,0 +1,26 @@
+package client
+
+import "fmt"
+
+// Validate проверяет запрос на чат до отправки на сервер
+func (r *ChatRequest) Validate() error {
+	return r.validate(0)
+}
+
+// validate проверяет запрос, дополнительно ограничивая число сообщений значением maxMessages (0 - без ограничения)
+func (r *ChatRequest) validate(maxMessages int) error {
+	if maxMessages > 0 && len(r.Messages) > maxMessages {
+		return fmt.Errorf("invalid chat request: %d messages exceed the limit of %d", len(r.Messages), maxMessages)
+	}
+
+	for i, msg := range r.Messages {
+		if !msg.Role.IsValid() {
+			return fmt.Errorf("invalid chat request: message %d has unsupported role %q", i, msg.Role)
+		}
+		if msg.Content == "" && msg.FunctionCall == nil && len(msg.Attachments) == 0 {
+			return fmt.Errorf("invalid chat request: message %d has no content, function call or attachments", i)
+		}
+	}
+
+	return nil
+}
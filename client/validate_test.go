@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChatRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		message ChatMessage
+		wantErr bool
+	}{
+		{name: "content", message: ChatMessage{Role: RoleUser, Content: "Hello"}},
+		{name: "function call", message: ChatMessage{Role: RoleAssistant, FunctionCall: &FunctionCall{Name: "get_weather"}}},
+		{name: "attachments", message: ChatMessage{Role: RoleUser, Attachments: []string{"file-1"}}},
+		{name: "empty", message: ChatMessage{Role: RoleUser}, wantErr: true},
+		{name: "unknown role", message: ChatMessage{Role: "tool", Content: "42"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ChatRequest{
+				Model: "GigaChat:latest",
+				Messages: []ChatMessage{
+					{Role: RoleSystem, Content: "You are a helpful assistant"},
+					tt.message,
+				},
+			}
+
+			err := req.Validate()
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), "message 1") {
+				t.Errorf("Expected error for message 1, got %v", err)
+			}
+		})
+	}
+}
+
+func TestChatValidatesRequest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request for invalid chat request")
+	})
+
+	_, err := client.Chat(context.Background(), &ChatRequest{
+		Model:    "GigaChat:latest",
+		Messages: []ChatMessage{{Role: RoleUser}},
+	})
+	if err == nil {
+		t.Error("Expected validation error")
+	}
+}
+
+func TestMaxMessages(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request exceeding the message limit")
+	}, WithMaxMessages(2))
+
+	messages := []ChatMessage{
+		{Role: RoleSystem, Content: "You are a helpful assistant"},
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi"},
+	}
+
+	_, err := client.Chat(context.Background(), &ChatRequest{Model: "GigaChat:latest", Messages: messages})
+	if err == nil || !strings.Contains(err.Error(), "3 messages exceed the limit of 2") {
+		t.Errorf("Expected message limit error, got %v", err)
+	}
+}
@@ -120,11 +120,12 @@ func main() {
 	} else {
 		if len(funcChatResp.Choices) > 0 {
 			choice := funcChatResp.Choices[0]
-			if choice.Message.FunctionCall != nil {
+			if choice.Message.HasContent() {
+				fmt.Printf("Response: %s\n", choice.Message.Content)
+			}
+			if choice.Message.HasFunctionCall() {
 				fmt.Printf("Function called: %s\n", choice.Message.FunctionCall.Name)
 				fmt.Printf("Args: %v\n", choice.Message.FunctionCall.Arguments)
-			} else {
-				fmt.Printf("Response: %s\n", choice.Message.Content)
 			}
 		}
 	}
@@ -167,8 +168,7 @@ func main() {
 		fmt.Printf("Langchaingo response: %s\n", response)
 	}
 
-	embeddingsLLM := model.New(gigaClient, "Embeddings")
-	embedder, err := embeddings.NewEmbedder(embeddingsLLM)
+	embedder, err := embeddings.NewEmbedder(model.NewEmbedder(gigaClient, "Embeddings"))
 	if err != nil {
 		log.Fatalf("Error creating Langchaingo embedder: %v", err)
 	}
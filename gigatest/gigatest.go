@@ -0,0 +1,229 @@
+// Package gigatest предоставляет mock сервер GigaChat API для тестов.
+package gigatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ValerySidorin/gigago/client"
+)
+
+// Server представляет mock сервер GigaChat API с настраиваемыми ответами
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	models     client.ModelsResponse
+	chat       client.ChatResponse
+	embeddings client.EmbeddingResponse
+	files      []client.File
+	// lastFileID только растет, чтобы ID файлов не повторялись после удаления
+	lastFileID        int
+	chatRequests      []client.ChatRequest
+	embeddingRequests []client.EmbeddingRequest
+}
+
+type Option func(*Server)
+
+func WithModels(models ...client.Model) Option {
+	return func(s *Server) {
+		s.models = client.ModelsResponse{Data: models}
+	}
+}
+
+func WithChatResponse(resp client.ChatResponse) Option {
+	return func(s *Server) {
+		s.chat = resp
+	}
+}
+
+func WithEmbeddingResponse(resp client.EmbeddingResponse) Option {
+	return func(s *Server) {
+		s.embeddings = resp
+	}
+}
+
+func WithFiles(files ...client.File) Option {
+	return func(s *Server) {
+		s.files = files
+	}
+}
+
+// NewServer запускает mock сервер. Сервер нужно остановить вызовом Close.
+func NewServer(opts ...Option) *Server {
+	s := &Server{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth", s.handleOAuth)
+	mux.HandleFunc("GET /models", s.handleModels)
+	mux.HandleFunc("POST /chat/completions", s.handleChat)
+	mux.HandleFunc("POST /embeddings", s.handleEmbeddings)
+	mux.HandleFunc("GET /files", s.handleListFiles)
+	mux.HandleFunc("POST /files", s.handleUploadFile)
+	mux.HandleFunc("GET /files/{id}", s.handleGetFile)
+	mux.HandleFunc("DELETE /files/{id}", s.handleDeleteFile)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// NewClient создает клиент, направленный на mock сервер
+func (s *Server) NewClient(opts ...client.Option) *client.Client {
+	opts = append([]client.Option{
+		client.WithHTTPClient(s.Client()),
+		client.WithBaseURL(s.URL),
+		client.WithAuthURL(s.URL + "/oauth"),
+	}, opts...)
+
+	return client.NewClient("gigatest", opts...)
+}
+
+// ChatRequests возвращает запросы, полученные сервером на /chat/completions
+func (s *Server) ChatRequests() []client.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]client.ChatRequest, len(s.chatRequests))
+	copy(requests, s.chatRequests)
+	return requests
+}
+
+// EmbeddingRequests возвращает запросы, полученные сервером на /embeddings
+func (s *Server) EmbeddingRequests() []client.EmbeddingRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]client.EmbeddingRequest, len(s.embeddingRequests))
+	copy(requests, s.embeddingRequests)
+	return requests
+}
+
+func (s *Server) handleOAuth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, client.TokenResponse{
+		AccessToken: "gigatest_token",
+		ExpiresAt:   time.Now().Add(30 * time.Minute).Unix(),
+	})
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, s.models)
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req client.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chatRequests = append(s.chatRequests, req)
+	writeJSON(w, s.chat)
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req client.EmbeddingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.embeddingRequests = append(s.embeddingRequests, req)
+	writeJSON(w, s.embeddings)
+}
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, client.FilesResponse{Data: s.files})
+}
+
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	upload, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer upload.Close()
+
+	n, _ := io.Copy(io.Discard, upload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := client.File{
+		ID:        s.newFileID(),
+		Object:    "file",
+		Bytes:     int(n),
+		CreatedAt: time.Now().Unix(),
+		Filename:  header.Filename,
+		Purpose:   r.FormValue("purpose"),
+	}
+	s.files = append(s.files, file)
+
+	writeJSON(w, file)
+}
+
+// newFileID возвращает ID для нового файла, не совпадающий с уже выданными и заданными
+// через WithFiles. Вызывается под s.mu.
+func (s *Server) newFileID() string {
+	for {
+		s.lastFileID++
+		id := fmt.Sprintf("file-%d", s.lastFileID)
+		if !slices.ContainsFunc(s.files, func(f client.File) bool { return f.ID == id }) {
+			return id
+		}
+	}
+}
+
+func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, file := range s.files {
+		if file.ID == r.PathValue("id") {
+			writeJSON(w, file)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, file := range s.files {
+		if file.ID == r.PathValue("id") {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			writeJSON(w, map[string]any{"id": file.ID, "deleted": true})
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,132 @@
+package gigatest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ValerySidorin/gigago/client"
+)
+
+func TestChatRoundTrip(t *testing.T) {
+	srv := NewServer(WithChatResponse(client.ChatResponse{
+		Model: "GigaChat:latest",
+		Choices: []client.ChatChoice{
+			{
+				Message: client.ChatMessage{
+					Role:    client.RoleAssistant,
+					Content: "Hello from gigatest!",
+				},
+			},
+		},
+	}))
+	defer srv.Close()
+
+	gigaClient := srv.NewClient()
+
+	resp, err := gigaClient.Chat(context.Background(), &client.ChatRequest{
+		Model: "GigaChat:latest",
+		Messages: []client.ChatMessage{
+			{
+				Role:    client.RoleUser,
+				Content: "Hello!",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if len(resp.Choices) != 1 {
+		t.Fatalf("Expected 1 choice, got %d", len(resp.Choices))
+	}
+
+	if resp.Choices[0].Message.Content != "Hello from gigatest!" {
+		t.Errorf("Expected content to be 'Hello from gigatest!', got '%s'", resp.Choices[0].Message.Content)
+	}
+
+	requests := srv.ChatRequests()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 chat request, got %d", len(requests))
+	}
+
+	if requests[0].Messages[0].Content != "Hello!" {
+		t.Errorf("Expected request content to be 'Hello!', got '%s'", requests[0].Messages[0].Content)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	srv := NewServer(WithFiles(client.File{ID: "file-1", Filename: "a.txt"}))
+	defer srv.Close()
+
+	gigaClient := srv.NewClient()
+	ctx := context.Background()
+
+	files, err := gigaClient.GetFiles(ctx)
+	if err != nil {
+		t.Fatalf("GetFiles failed: %v", err)
+	}
+
+	if len(files.Data) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(files.Data))
+	}
+
+	if err := gigaClient.DeleteFile(ctx, "file-1"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+
+	if _, err := gigaClient.GetFile(ctx, "file-1"); err == nil {
+		t.Error("Expected error for deleted file")
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	gigaClient := srv.NewClient()
+
+	file, err := gigaClient.UploadFileReader(context.Background(),
+		strings.NewReader("hello"), "notes.txt", "text/plain", client.General)
+	if err != nil {
+		t.Fatalf("UploadFileReader failed: %v", err)
+	}
+
+	if file.Filename != "notes.txt" || file.Purpose != "general" || file.Bytes != 5 {
+		t.Errorf("Expected notes.txt (general, 5 bytes), got %s (%s, %d bytes)", file.Filename, file.Purpose, file.Bytes)
+	}
+}
+
+func TestUploadFileIDsAfterDelete(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	gigaClient := srv.NewClient()
+
+	upload := func() string {
+		file, err := gigaClient.UploadFileReader(ctx, strings.NewReader("hello"), "notes.txt", "text/plain", client.General)
+		if err != nil {
+			t.Fatalf("UploadFileReader failed: %v", err)
+		}
+		return file.ID
+	}
+
+	first, second := upload(), upload()
+	if err := gigaClient.DeleteFile(ctx, first); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	third := upload()
+
+	if third == first || third == second {
+		t.Errorf("Expected a new ID after delete, got %s (existing %s, deleted %s)", third, second, first)
+	}
+
+	files, err := gigaClient.GetFiles(ctx)
+	if err != nil {
+		t.Fatalf("GetFiles failed: %v", err)
+	}
+	if len(files.Data) != 2 {
+		t.Errorf("Expected 2 files after delete and upload, got %d", len(files.Data))
+	}
+}
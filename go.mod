@@ -5,6 +5,7 @@ go 1.24.3
 require (
 	github.com/google/uuid v1.6.0
 	github.com/tmc/langchaingo v0.1.13
+	golang.org/x/time v0.5.0
 )
 
 require (
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+
+	"github.com/ValerySidorin/gigago/client"
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// Embedder создает эмбеддинги через GigaChat независимо от чат-модели LLM
+type Embedder struct {
+	gigaClient *client.Client
+	model      string
+}
+
+var _ embeddings.EmbedderClient = (*Embedder)(nil)
+
+// NewEmbedder создает Embedder, использующий модель эмбеддингов model (например, "Embeddings")
+func NewEmbedder(gigaClient *client.Client, model string) *Embedder {
+	return &Embedder{
+		gigaClient: gigaClient,
+		model:      model,
+	}
+}
+
+func (e *Embedder) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	return createEmbedding(ctx, e.gigaClient, e.model, texts)
+}
+
+// createEmbedding запрашивает эмбеддинги и приводит их к формату langchaingo
+func createEmbedding(
+	ctx context.Context, gigaClient *client.Client, model string, texts []string,
+) ([][]float32, error) {
+	req := &client.EmbeddingRequest{
+		Model: model,
+		Input: texts,
+	}
+	resp, err := gigaClient.CreateEmbeddings(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]float32, len(resp.Data))
+	for i, emb := range resp.Data {
+		vec := make([]float32, len(emb.Embedding))
+		for j, v := range emb.Embedding {
+			vec[j] = float32(v)
+		}
+		result[i] = vec
+	}
+	return result, nil
+}
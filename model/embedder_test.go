@@ -0,0 +1,39 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ValerySidorin/gigago/client"
+	"github.com/ValerySidorin/gigago/gigatest"
+)
+
+func TestEmbedder(t *testing.T) {
+	srv := gigatest.NewServer(gigatest.WithEmbeddingResponse(client.EmbeddingResponse{
+		Object: "list",
+		Data: []client.Embedding{
+			{Object: "embedding", Embedding: []float64{0.1, 0.2}, Index: 0},
+		},
+	}))
+	defer srv.Close()
+
+	embedder := NewEmbedder(srv.NewClient(), "Embeddings")
+
+	vectors, err := embedder.CreateEmbedding(context.Background(), []string{"Hello"})
+	if err != nil {
+		t.Fatalf("CreateEmbedding failed: %v", err)
+	}
+
+	if len(vectors) != 1 || len(vectors[0]) != 2 {
+		t.Fatalf("Expected one 2-dimensional vector, got %v", vectors)
+	}
+
+	requests := srv.EmbeddingRequests()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 embedding request, got %d", len(requests))
+	}
+
+	if requests[0].Model != "Embeddings" {
+		t.Errorf("Expected model to be 'Embeddings', got '%s'", requests[0].Model)
+	}
+}
@@ -2,9 +2,14 @@ package model
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 
 	"github.com/ValerySidorin/gigago/client"
+	"github.com/google/uuid"
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/llms"
 )
@@ -14,9 +19,17 @@ const (
 	RoleAssistant = "assistant"
 )
 
+// promptCacheKey - ключ llms.CallOptions.Metadata для WithPromptCache
+const promptCacheKey = "gigachat_prompt_cache"
+
+// streamBufferKey - ключ llms.CallOptions.Metadata для WithChatStreamBufferedChannel
+const streamBufferKey = "gigachat_stream_buffer"
+
 type LLM struct {
 	gigaClient *client.Client
 	model      string
+	// sessionID используется как X-Session-ID при включенном кэшировании промптов
+	sessionID string
 }
 
 var _ llms.Model = (*LLM)(nil)
@@ -26,25 +39,106 @@ func New(gigaClient *client.Client, model string) *LLM {
 	return &LLM{
 		gigaClient: gigaClient,
 		model:      model,
+		sessionID:  uuid.New().String(),
+	}
+}
+
+// WithPromptCache включает кэширование промптов на стороне GigaChat: запросы
+// экземпляра LLM отправляются с общим X-Session-ID, что позволяет серверу
+// переиспользовать уже обработанный контекст (например, длинный системный промпт).
+// Кэширование работает по принципу best-effort: сервер может его не применить.
+// Число токенов, взятых из кэша, возвращается в GenerationInfo["PrecachedPromptTokens"].
+func WithPromptCache(enabled bool) llms.CallOption {
+	return func(opts *llms.CallOptions) {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]any)
+		}
+		opts.Metadata[promptCacheKey] = enabled
+	}
+}
+
+// WithChatStreamBufferedChannel задает размер буфера канала фрагментов CallStream.
+// Буфер позволяет потоку ответа не ждать медленного потребителя.
+// По умолчанию канал небуферизованный.
+func WithChatStreamBufferedChannel(size int) llms.CallOption {
+	return func(opts *llms.CallOptions) {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]any)
+		}
+		opts.Metadata[streamBufferKey] = size
+	}
+}
+
+// CallStream работает как Call с потоковым ответом, но передает фрагменты текста в канал.
+// Канал фрагментов закрывается по завершении потока, после чего в канал ошибок
+// передается ошибка потока или nil. При отмене ctx фрагменты перестают отправляться.
+func (o *LLM) CallStream(
+	ctx context.Context, prompt string, options ...llms.CallOption,
+) (<-chan string, <-chan error) {
+	opts := &llms.CallOptions{}
+	for _, opt := range options {
+		opt(opts)
 	}
+
+	size, _ := opts.Metadata[streamBufferKey].(int)
+	chunks := make(chan string, max(size, 0))
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+
+		options := append(slices.Clone(options), llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			select {
+			case chunks <- string(chunk):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}))
+		_, err := o.Call(ctx, prompt, options...)
+		close(chunks)
+		errs <- err
+	}()
+
+	return chunks, errs
 }
 
+// Call отправляет prompt как сообщение пользователя и возвращает текст ответа модели.
+// С llms.WithStreamingFunc ответ запрашивается потоком и передается в функцию
+// по фрагментам. Если ctx отменяется или истекает во время потока, Call возвращает
+// уже полученный текст вместе с ошибкой, для которой errors.Is(err, ctx.Err()) истинно.
 func (o *LLM) Call(
 	ctx context.Context, prompt string, options ...llms.CallOption,
 ) (string, error) {
+	message, err := o.CallMessage(ctx, prompt, options...)
+	return message.Content, err
+}
+
+// CallMessage работает как Call, но возвращает сообщение ассистента целиком,
+// включая запрошенный моделью вызов функции
+func (o *LLM) CallMessage(
+	ctx context.Context, prompt string, options ...llms.CallOption,
+) (client.ChatMessage, error) {
+	opts := &llms.CallOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	functions, err := toFunctions(opts)
+	if err != nil {
+		return client.ChatMessage{}, err
+	}
+
 	chatReq := &client.ChatRequest{
-		Model: o.model,
+		Model: o.modelName(opts),
 		Messages: []client.ChatMessage{
 			{
 				Role:    "user",
 				Content: prompt,
 			},
 		},
-	}
-
-	opts := &llms.CallOptions{}
-	for _, opt := range options {
-		opt(opts)
+		Functions:    functions,
+		FunctionCall: toFunctionCallMode(opts),
 	}
 
 	if opts.Temperature > 0 {
@@ -56,16 +150,24 @@ func (o *LLM) Call(
 		chatReq.MaxTokens = &maxTokens
 	}
 
-	resp, err := o.gigaClient.Chat(ctx, chatReq)
+	if opts.StreamingFunc != nil {
+		message, err := o.streamCall(o.callContext(ctx, opts), chatReq, opts.StreamingFunc)
+		if err != nil {
+			return message, fmt.Errorf("failed to call GigaChat: %w", err)
+		}
+		return message, nil
+	}
+
+	resp, err := o.gigaClient.Chat(o.callContext(ctx, opts), chatReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to call GigaChat: %w", err)
+		return client.ChatMessage{}, fmt.Errorf("failed to call GigaChat: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from GigaChat")
+		return client.ChatMessage{}, fmt.Errorf("no response from GigaChat")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message, nil
 }
 
 func (o *LLM) GenerateContent(
@@ -73,11 +175,24 @@ func (o *LLM) GenerateContent(
 ) (*llms.ContentResponse, error) {
 	chatMessages := make([]client.ChatMessage, len(messages))
 	for i, msg := range messages {
-		var content string
+		var (
+			content      string
+			functionCall *client.FunctionCall
+		)
 		for _, part := range msg.Parts {
-			if textPart, ok := part.(llms.TextContent); ok {
-				content = textPart.Text
-				break
+			switch part := part.(type) {
+			case llms.TextContent:
+				if content == "" {
+					content = part.Text
+				}
+			case llms.ToolCallResponse:
+				content = part.Content
+			case llms.ToolCall:
+				call, err := toFunctionCall(part)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: %w", i, err)
+				}
+				functionCall = call
 			}
 		}
 
@@ -89,28 +204,39 @@ func (o *LLM) GenerateContent(
 			role = client.RoleUser
 		case llms.ChatMessageTypeAI:
 			role = client.RoleAssistant
-		case llms.ChatMessageTypeFunction:
+		case llms.ChatMessageTypeFunction, llms.ChatMessageTypeTool:
 			role = client.RoleFunction
 		default:
-			return nil, fmt.Errorf("role %v not supported", msg.Role)
+			return nil, fmt.Errorf(
+				"message %d: role %q not supported by GigaChat (expected system, human, generic, ai, function or tool)",
+				i, msg.Role,
+			)
 		}
 
 		chatMessages[i] = client.ChatMessage{
-			Role:    role,
-			Content: content,
+			Role:         role,
+			Content:      content,
+			FunctionCall: functionCall,
 		}
 	}
 
-	chatReq := &client.ChatRequest{
-		Model:    o.model,
-		Messages: chatMessages,
-	}
-
 	opts := &llms.CallOptions{}
 	for _, opt := range options {
 		opt(opts)
 	}
 
+	functions, err := toFunctions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	chatReq := &client.ChatRequest{
+		Model:        o.modelName(opts),
+		Messages:     chatMessages,
+		Functions:    functions,
+		FunctionCall: toFunctionCallMode(opts),
+	}
+
 	if opts.Temperature > 0 {
 		temp := opts.Temperature
 		chatReq.Temperature = &temp
@@ -120,7 +246,7 @@ func (o *LLM) GenerateContent(
 		chatReq.MaxTokens = &maxTokens
 	}
 
-	resp, err := o.gigaClient.Chat(ctx, chatReq)
+	resp, err := o.gigaClient.Chat(o.callContext(ctx, opts), chatReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate content: %w", err)
 	}
@@ -129,33 +255,183 @@ func (o *LLM) GenerateContent(
 		return nil, fmt.Errorf("no response from GigaChat")
 	}
 
-	content := resp.Choices[0].Message.Content
-	return &llms.ContentResponse{
-		Choices: []*llms.ContentChoice{
-			{
-				Content: content,
-			},
+	message := resp.Choices[0].Message
+	choice := &llms.ContentChoice{
+		Content: message.Content,
+		GenerationInfo: map[string]any{
+			"PromptTokens":          resp.Usage.PromptTokens,
+			"CompletionTokens":      resp.Usage.CompletionTokens,
+			"TotalTokens":           resp.Usage.TotalTokens,
+			"PrecachedPromptTokens": resp.Usage.PrecachedPromptTokens,
 		},
+	}
+
+	if message.HasFunctionCall() {
+		args, err := json.Marshal(message.FunctionCall.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode function arguments: %w", err)
+		}
+
+		call := &llms.FunctionCall{
+			Name:      message.FunctionCall.Name,
+			Arguments: string(args),
+		}
+		choice.FuncCall = call
+		choice.ToolCalls = []llms.ToolCall{{Type: "function", FunctionCall: call}}
+	}
+
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{choice},
 	}, nil
 }
 
-func (o *LLM) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
-	req := &client.EmbeddingRequest{
-		Model: o.model,
-		Input: texts,
+// streamCall выполняет запрос потоком, передавая фрагменты текста в streamingFunc,
+// и возвращает собранное из фрагментов сообщение, включая вызов функции. При отмене ctx
+// накопленное сообщение возвращается вместе с ctx.Err().
+func (o *LLM) streamCall(
+	ctx context.Context, req *client.ChatRequest, streamingFunc func(ctx context.Context, chunk []byte) error,
+) (client.ChatMessage, error) {
+	message := client.ChatMessage{Role: client.RoleAssistant}
+
+	stream, err := o.gigaClient.ChatStream(ctx, req)
+	if err != nil {
+		return message, err
+	}
+	defer stream.Close()
+
+	for {
+		chunk, err := stream.Recv()
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			return message, ctxErr
+		}
+		if errors.Is(err, io.EOF) {
+			return message, nil
+		}
+		if err != nil {
+			return message, err
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		message.AppendDelta(delta)
+		if delta.Content == "" {
+			continue
+		}
+		if err := streamingFunc(ctx, []byte(delta.Content)); err != nil {
+			return message, fmt.Errorf("streaming function failed: %w", err)
+		}
+	}
+}
+
+// toFunctions преобразует инструменты и функции langchaingo в функции GigaChat
+func toFunctions(opts *llms.CallOptions) ([]client.Function, error) {
+	definitions := slices.Clone(opts.Functions)
+	for _, tool := range opts.Tools {
+		if tool.Type != "function" || tool.Function == nil {
+			return nil, fmt.Errorf("tool type %q not supported by GigaChat (expected function)", tool.Type)
+		}
+		definitions = append(definitions, *tool.Function)
+	}
+
+	functions := make([]client.Function, 0, len(definitions))
+	for _, def := range definitions {
+		params, err := toMap(def.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: invalid parameters: %w", def.Name, err)
+		}
+
+		functions = append(functions, client.Function{
+			Name:        def.Name,
+			Description: def.Description,
+			Parameters:  params,
+		})
+	}
+
+	return functions, nil
+}
+
+// toFunctionCallMode преобразует выбор инструмента langchaingo в значение function_call GigaChat
+func toFunctionCallMode(opts *llms.CallOptions) any {
+	switch choice := opts.ToolChoice.(type) {
+	case string:
+		return choice
+	case llms.ToolChoice:
+		if choice.Function != nil {
+			return map[string]string{"name": choice.Function.Name}
+		}
+	case *llms.ToolChoice:
+		if choice != nil && choice.Function != nil {
+			return map[string]string{"name": choice.Function.Name}
+		}
+	}
+
+	if opts.FunctionCallBehavior != "" {
+		return string(opts.FunctionCallBehavior)
+	}
+
+	return nil
+}
+
+// toFunctionCall преобразует вызов инструмента langchaingo в вызов функции GigaChat
+func toFunctionCall(call llms.ToolCall) (*client.FunctionCall, error) {
+	if call.FunctionCall == nil {
+		return nil, fmt.Errorf("tool call %q has no function", call.ID)
+	}
+
+	var args map[string]any
+	if call.FunctionCall.Arguments != "" {
+		if err := json.Unmarshal([]byte(call.FunctionCall.Arguments), &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments of function %s: %w", call.FunctionCall.Name, err)
+		}
+	}
+
+	return &client.FunctionCall{
+		Name:      call.FunctionCall.Name,
+		Arguments: args,
+	}, nil
+}
+
+// toMap преобразует параметры функции в JSON-объект
+func toMap(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
 	}
-	resp, err := o.gigaClient.CreateEmbeddings(ctx, req)
+	if m, ok := v.(map[string]any); ok {
+		return m, nil
+	}
+
+	data, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([][]float32, len(resp.Data))
-	for i, emb := range resp.Data {
-		vec := make([]float32, len(emb.Embedding))
-		for j, v := range emb.Embedding {
-			vec[j] = float32(v)
-		}
-		result[i] = vec
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// callContext добавляет в контекст X-Session-ID, если включено кэширование промптов
+func (o *LLM) callContext(ctx context.Context, opts *llms.CallOptions) context.Context {
+	if enabled, _ := opts.Metadata[promptCacheKey].(bool); enabled {
+		return client.WithSessionID(ctx, o.sessionID)
+	}
+	return ctx
+}
+
+// modelName возвращает модель, переданную через llms.WithModel, или модель по умолчанию
+func (o *LLM) modelName(opts *llms.CallOptions) string {
+	if opts.Model != "" {
+		return opts.Model
 	}
-	return result, nil
+	return o.model
+}
+
+func (o *LLM) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	return createEmbedding(ctx, o.gigaClient, o.model, texts)
 }
@@ -2,9 +2,17 @@ package model
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ValerySidorin/gigago/client"
+	"github.com/ValerySidorin/gigago/gigatest"
 	"github.com/tmc/langchaingo/llms"
 )
 
@@ -59,3 +67,423 @@ func TestGenerateContentRequestStructure(t *testing.T) {
 		t.Error("Expected error with invalid credentials")
 	}
 }
+
+func TestModelOverride(t *testing.T) {
+	srv := gigatest.NewServer(gigatest.WithChatResponse(client.ChatResponse{
+		Choices: []client.ChatChoice{
+			{
+				Message: client.ChatMessage{
+					Role:    client.RoleAssistant,
+					Content: "OK",
+				},
+			},
+		},
+	}))
+	defer srv.Close()
+
+	llm := New(srv.NewClient(), "GigaChat:latest")
+	ctx := context.Background()
+
+	if _, err := llm.Call(ctx, "Hello"); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if _, err := llm.Call(ctx, "Hello", llms.WithModel("GigaChat-Pro")); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}
+	if _, err := llm.GenerateContent(ctx, messages, llms.WithModel("GigaChat-Max")); err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+
+	requests := srv.ChatRequests()
+	expected := []string{"GigaChat:latest", "GigaChat-Pro", "GigaChat-Max"}
+	if len(requests) != len(expected) {
+		t.Fatalf("Expected %d requests, got %d", len(expected), len(requests))
+	}
+
+	for i, model := range expected {
+		if requests[i].Model != model {
+			t.Errorf("Expected request %d model to be '%s', got '%s'", i, model, requests[i].Model)
+		}
+	}
+}
+
+func TestPromptCache(t *testing.T) {
+	var sessionIDs []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.TokenResponse{
+			AccessToken: "test_token",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	})
+	mux.HandleFunc("/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		sessionIDs = append(sessionIDs, r.Header.Get("X-Session-ID"))
+		json.NewEncoder(w).Encode(client.ChatResponse{
+			Choices: []client.ChatChoice{
+				{Message: client.ChatMessage{Role: client.RoleAssistant, Content: "OK"}},
+			},
+			Usage: client.Usage{PromptTokens: 100, PrecachedPromptTokens: 80},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	gigaClient := client.NewClient("test_auth_key",
+		client.WithBaseURL(srv.URL),
+		client.WithAuthURL(srv.URL+"/oauth"),
+	)
+	llm := New(gigaClient, "GigaChat:latest")
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}
+
+	if _, err := llm.GenerateContent(ctx, messages); err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+
+	resp, err := llm.GenerateContent(ctx, messages, WithPromptCache(true))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+
+	if sessionIDs[0] != "" {
+		t.Errorf("Expected no X-Session-ID without prompt cache, got '%s'", sessionIDs[0])
+	}
+
+	if sessionIDs[1] != llm.sessionID {
+		t.Errorf("Expected X-Session-ID to be '%s', got '%s'", llm.sessionID, sessionIDs[1])
+	}
+
+	if got := resp.Choices[0].GenerationInfo["PrecachedPromptTokens"]; got != 80 {
+		t.Errorf("Expected PrecachedPromptTokens to be 80, got %v", got)
+	}
+}
+
+func TestGenerateContentUnsupportedRole(t *testing.T) {
+	srv := gigatest.NewServer()
+	defer srv.Close()
+
+	llm := New(srv.NewClient(), "GigaChat:latest")
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+		llms.TextParts(llms.ChatMessageType("bogus"), "Hello"),
+	}
+
+	_, err := llm.GenerateContent(context.Background(), messages)
+	if err == nil || !strings.Contains(err.Error(), `role "bogus" not supported`) {
+		t.Errorf("Expected unsupported role error, got %v", err)
+	}
+
+	if len(srv.ChatRequests()) != 0 {
+		t.Error("Expected no request to be sent for an unsupported role")
+	}
+}
+
+func TestGenerateContentTools(t *testing.T) {
+	srv := gigatest.NewServer(gigatest.WithChatResponse(client.ChatResponse{
+		Choices: []client.ChatChoice{
+			{
+				Message: client.ChatMessage{
+					Role: client.RoleAssistant,
+					FunctionCall: &client.FunctionCall{
+						Name:      "get_weather",
+						Arguments: map[string]any{"city": "Moscow"},
+					},
+				},
+			},
+		},
+	}))
+	defer srv.Close()
+
+	llm := New(srv.NewClient(), "GigaChat:latest")
+	tool := llms.Tool{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get the weather in a specified city",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			},
+		},
+	}
+
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "What's the weather in Moscow?")}
+	resp, err := llm.GenerateContent(context.Background(), messages, llms.WithTools([]llms.Tool{tool}))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+
+	requests := srv.ChatRequests()
+	if len(requests) != 1 || len(requests[0].Functions) != 1 {
+		t.Fatalf("Expected one request with one function, got %+v", requests)
+	}
+
+	function := requests[0].Functions[0]
+	if function.Name != "get_weather" || function.Description != tool.Function.Description || function.Parameters["type"] != "object" {
+		t.Errorf("Expected function to mirror the tool definition, got %+v", function)
+	}
+
+	choice := resp.Choices[0]
+	if len(choice.ToolCalls) != 1 || choice.ToolCalls[0].FunctionCall.Name != "get_weather" {
+		t.Fatalf("Expected a get_weather tool call, got %+v", choice.ToolCalls)
+	}
+
+	if args := choice.ToolCalls[0].FunctionCall.Arguments; args != `{"city":"Moscow"}` {
+		t.Errorf("Expected arguments '{\"city\":\"Moscow\"}', got '%s'", args)
+	}
+
+	if choice.FuncCall == nil || choice.FuncCall.Name != "get_weather" {
+		t.Errorf("Expected FuncCall to be set, got %+v", choice.FuncCall)
+	}
+}
+
+func TestGenerateContentToolRoundTrip(t *testing.T) {
+	srv := gigatest.NewServer(gigatest.WithChatResponse(client.ChatResponse{
+		Choices: []client.ChatChoice{
+			{Message: client.ChatMessage{Role: client.RoleAssistant, Content: "It's 20 degrees"}},
+		},
+	}))
+	defer srv.Close()
+
+	llm := New(srv.NewClient(), "GigaChat:latest")
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "What's the weather in Moscow?"),
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				Type:         "function",
+				FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"city":"Moscow"}`},
+			}},
+		},
+		{
+			Role:  llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{Name: "get_weather", Content: `{"temp":20}`}},
+		},
+	}
+
+	if _, err := llm.GenerateContent(context.Background(), messages); err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+
+	sent := srv.ChatRequests()[0].Messages
+	if call := sent[1].FunctionCall; call == nil || call.Name != "get_weather" || call.Arguments["city"] != "Moscow" {
+		t.Errorf("Expected assistant function call to be forwarded, got %+v", sent[1])
+	}
+
+	if sent[2].Role != client.RoleFunction || sent[2].Content != `{"temp":20}` {
+		t.Errorf("Expected function result message, got %+v", sent[2])
+	}
+}
+
+// newStreamServer запускает сервер, отдающий chunks потоком SSE. Если done ложно,
+// после фрагментов сервер не завершает поток, пока клиент не отключится.
+func newStreamServer(t *testing.T, chunks []string, done bool) *httptest.Server {
+	t.Helper()
+
+	deltas := make([]client.ChatMessage, len(chunks))
+	for i, chunk := range chunks {
+		deltas[i] = client.ChatMessage{Content: chunk}
+	}
+	return newDeltaStreamServer(t, deltas, done)
+}
+
+// newDeltaStreamServer работает как newStreamServer, но отдает фрагменты сообщения целиком
+func newDeltaStreamServer(t *testing.T, deltas []client.ChatMessage, done bool) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.TokenResponse{
+			AccessToken: "test_token",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+	})
+	mux.HandleFunc("/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range deltas {
+			data, _ := json.Marshal(client.ChatResponse{
+				Choices: []client.ChatChoice{{Delta: delta}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+
+		if done {
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		<-r.Context().Done()
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCallStreaming(t *testing.T) {
+	srv := newStreamServer(t, []string{"Hel", "lo"}, true)
+	gigaClient := client.NewClient("test_auth_key",
+		client.WithBaseURL(srv.URL),
+		client.WithAuthURL(srv.URL+"/oauth"),
+	)
+	llm := New(gigaClient, "GigaChat:latest")
+
+	var streamed []string
+	content, err := llm.Call(context.Background(), "Hi", llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		streamed = append(streamed, string(chunk))
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if content != "Hello" {
+		t.Errorf("Expected content to be 'Hello', got '%s'", content)
+	}
+
+	if strings.Join(streamed, "|") != "Hel|lo" {
+		t.Errorf("Expected chunks 'Hel|lo', got '%s'", strings.Join(streamed, "|"))
+	}
+}
+
+func TestCallStreamingDeadline(t *testing.T) {
+	srv := newStreamServer(t, []string{"Hel", "lo"}, false)
+	gigaClient := client.NewClient("test_auth_key",
+		client.WithBaseURL(srv.URL),
+		client.WithAuthURL(srv.URL+"/oauth"),
+	)
+	llm := New(gigaClient, "GigaChat:latest")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var streamed int
+	content, err := llm.Call(ctx, "Hi", llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		streamed++
+		return nil
+	}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if content != "Hello" {
+		t.Errorf("Expected partial content 'Hello', got '%s'", content)
+	}
+
+	if streamed != 2 {
+		t.Errorf("Expected 2 streamed chunks before the deadline, got %d", streamed)
+	}
+}
+
+func TestCallMessageFunctionCall(t *testing.T) {
+	srv := gigatest.NewServer(gigatest.WithChatResponse(client.ChatResponse{
+		Choices: []client.ChatChoice{
+			{
+				Message: client.ChatMessage{
+					Role: client.RoleAssistant,
+					FunctionCall: &client.FunctionCall{
+						Name:      "get_weather",
+						Arguments: map[string]any{"city": "Moscow"},
+					},
+				},
+			},
+		},
+	}))
+	defer srv.Close()
+
+	llm := New(srv.NewClient(), "GigaChat:latest")
+	function := llms.FunctionDefinition{Name: "get_weather", Description: "Get the weather in a specified city"}
+
+	message, err := llm.CallMessage(context.Background(), "What's the weather in Moscow?", llms.WithFunctions([]llms.FunctionDefinition{function}))
+	if err != nil {
+		t.Fatalf("CallMessage failed: %v", err)
+	}
+
+	if requests := srv.ChatRequests(); len(requests) != 1 || len(requests[0].Functions) != 1 {
+		t.Fatalf("Expected one request with one function, got %+v", requests)
+	}
+
+	if !message.HasFunctionCall() || message.FunctionCall.Name != "get_weather" || message.FunctionCall.Arguments["city"] != "Moscow" {
+		t.Errorf("Expected get_weather function call, got %+v", message.FunctionCall)
+	}
+
+	content, err := llm.Call(context.Background(), "What's the weather in Moscow?")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if content != "" {
+		t.Errorf("Expected empty content for function call response, got '%s'", content)
+	}
+}
+
+func TestCallMessageStreamingFunctionCall(t *testing.T) {
+	srv := newDeltaStreamServer(t, []client.ChatMessage{
+		{Role: client.RoleAssistant},
+		{FunctionCall: &client.FunctionCall{Name: "get_weather"}},
+		{FunctionCall: &client.FunctionCall{Arguments: map[string]any{"city": "Moscow"}}},
+	}, true)
+	gigaClient := client.NewClient("test_auth_key",
+		client.WithBaseURL(srv.URL),
+		client.WithAuthURL(srv.URL+"/oauth"),
+	)
+	llm := New(gigaClient, "GigaChat:latest")
+
+	var streamed int
+	message, err := llm.CallMessage(context.Background(), "What's the weather in Moscow?",
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed++
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("CallMessage failed: %v", err)
+	}
+
+	if !message.HasFunctionCall() || message.FunctionCall.Name != "get_weather" || message.FunctionCall.Arguments["city"] != "Moscow" {
+		t.Errorf("Expected streamed get_weather function call, got %+v", message.FunctionCall)
+	}
+
+	if message.Role != client.RoleAssistant || message.Content != "" {
+		t.Errorf("Expected empty assistant message, got %+v", message)
+	}
+
+	if streamed != 0 {
+		t.Errorf("Expected no text chunks for a function call, got %d", streamed)
+	}
+}
+
+func TestCallStreamBufferedChannel(t *testing.T) {
+	srv := newStreamServer(t, []string{"One", " two", " three"}, true)
+	gigaClient := client.NewClient("test_auth_key",
+		client.WithBaseURL(srv.URL),
+		client.WithAuthURL(srv.URL+"/oauth"),
+	)
+	llm := New(gigaClient, "GigaChat:latest")
+
+	chunks, errs := llm.CallStream(context.Background(), "Hi", WithChatStreamBufferedChannel(4))
+
+	// Поток завершается, не дожидаясь чтения фрагментов: они помещаются в буфер
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("CallStream failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected stream to complete without a consumer")
+	}
+
+	var got []string
+	for chunk := range chunks {
+		time.Sleep(10 * time.Millisecond)
+		got = append(got, chunk)
+	}
+
+	if strings.Join(got, "") != "One two three" {
+		t.Errorf("Expected chunks 'One two three', got %q", got)
+	}
+}